@@ -22,6 +22,8 @@ import (
 	pciaddress "github.com/zededa/ghw/pkg/pci/address"
 	"github.com/zededa/ghw/pkg/product"
 	"github.com/zededa/ghw/pkg/serial"
+	"github.com/zededa/ghw/pkg/serial/naming"
+	"github.com/zededa/ghw/pkg/thunderbolt"
 	"github.com/zededa/ghw/pkg/topology"
 	"github.com/zededa/ghw/pkg/tpm"
 	"github.com/zededa/ghw/pkg/usb"
@@ -40,6 +42,11 @@ var (
 	WithDisableWarnings = option.WithNullAlerter
 	WithDisableTools    = option.WithDisableTools
 	WithPathOverrides   = option.WithPathOverrides
+	WithPCIDB           = option.WithPCIDB
+	WithEmbeddedPCIDB   = option.WithEmbeddedPCIDB
+	WithPCIVPD          = option.WithPCIVPD
+	WithUSBDB           = usb.WithUSBDB
+	WithSerialNaming    = serial.WithNaming
 )
 
 type PathOverrides = option.PathOverrides
@@ -202,6 +209,8 @@ var (
 
 type SerialInfo = serial.Info
 type SerialDevice = serial.Device
+type SerialNamingPolicy = naming.Policy
+type SerialNamingRule = naming.Rule
 
 var (
 	Serial = serial.New
@@ -224,3 +233,11 @@ type WatchdogInfo = watchdog.Info
 var (
 	Watchdog = watchdog.New
 )
+
+type ThunderboltInfo = thunderbolt.Info
+type ThunderboltDomain = thunderbolt.Domain
+type ThunderboltRouter = thunderbolt.Router
+
+var (
+	Thunderbolt = thunderbolt.New
+)
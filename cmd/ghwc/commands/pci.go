@@ -12,6 +12,13 @@ import (
 	"github.com/zededa/ghw"
 )
 
+var (
+	// show the PCI bridge hierarchy instead of the device list
+	pciTree bool
+	// read the Vital Product Data of the devices
+	pciVPD bool
+)
+
 // pciCmd represents the install command
 var pciCmd = &cobra.Command{
 	Use:   "pci",
@@ -22,15 +29,49 @@ var pciCmd = &cobra.Command{
 // showPCI shows information for PCI devices on the host system.
 func showPCI(cmd *cobra.Command, args []string) error {
 	opts := cmd.Context().Value(optsKey).([]ghw.Option)
+	if pciVPD {
+		opts = append(opts, ghw.WithPCIVPD())
+	}
 	pci, err := ghw.PCI(opts...)
 	if err != nil {
 		return errors.Wrap(err, "error getting PCI info")
 	}
 
+	if pciTree {
+		printInfo(pci.Tree())
+		return nil
+	}
 	printInfo(pci)
 	return nil
 }
 
+// pciUnresolvedCmd represents the command listing unresolved PCI IDs
+var pciUnresolvedCmd = &cobra.Command{
+	Use:   "unresolved",
+	Short: "Show the PCI IDs missing from the PCI database",
+	RunE:  showPCIUnresolved,
+}
+
+// showPCIUnresolved shows the vendor, product and class IDs of the host PCI
+// devices the PCI database has no name for.
+func showPCIUnresolved(cmd *cobra.Command, args []string) error {
+	opts := cmd.Context().Value(optsKey).([]ghw.Option)
+	pci, err := ghw.PCI(opts...)
+	if err != nil {
+		return errors.Wrap(err, "error getting PCI info")
+	}
+
+	printInfo(pci.UnresolvedIDs())
+	return nil
+}
+
 func init() {
+	pciCmd.AddCommand(pciUnresolvedCmd)
+	pciCmd.Flags().BoolVar(
+		&pciTree, "tree", false, "Show the PCI bridge hierarchy as a tree",
+	)
+	pciCmd.Flags().BoolVar(
+		&pciVPD, "vpd", false, "Read the Vital Product Data of the devices, which is slow on some devices",
+	)
 	rootCmd.AddCommand(pciCmd)
 }
@@ -15,6 +15,8 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/zededa/ghw"
 	"github.com/zededa/ghw/pkg/option"
+	// the PCI database --embedded-pcidb falls back to
+	_ "github.com/zededa/ghw/pkg/pci/pciids"
 	"github.com/zededa/ghw/pkg/snapshot"
 )
 
@@ -30,7 +32,9 @@ const (
 	outputFormatYAML  = "yaml"
 	usageOutputFormat = `Output format.
 Choices are 'json','yaml', and 'human'.`
-	usageSnapshotPath = `Specify path to snapshot.`
+	usageSnapshotPath  = `Specify path to snapshot.`
+	usageEmbeddedPCIDB = `Use the PCI database embedded in ghwc
+if the host has none.`
 )
 
 var (
@@ -44,8 +48,9 @@ var (
 		outputFormatJSON,
 		outputFormatYAML,
 	}
-	snapshotPath string
-	pretty       bool
+	snapshotPath  string
+	pretty        bool
+	embeddedPCIDB bool
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -87,6 +92,9 @@ func init() {
 		"",
 		usageSnapshotPath,
 	)
+	rootCmd.PersistentFlags().BoolVar(
+		&embeddedPCIDB, "embedded-pcidb", false, usageEmbeddedPCIDB,
+	)
 }
 
 func showAll(cmd *cobra.Command, args []string) error {
@@ -192,6 +200,9 @@ func doPreRun(cmd *cobra.Command, args []string) error {
 			return nil
 		}
 	}
+	if embeddedPCIDB {
+		opts = append(opts, ghw.WithEmbeddedPCIDB())
+	}
 	ctx := context.TODO()
 	ctx = context.WithValue(ctx, optsKey, opts)
 	cmd.SetContext(ctx)
@@ -10,10 +10,18 @@ import (
 	"fmt"
 
 	"github.com/zededa/ghw"
+	"github.com/zededa/ghw/pkg/serial/naming"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
+var (
+	// the comma-separated sources to name serial ports from
+	serialNaming string
+	// the rules the "rules" source names serial ports after
+	serialNamingRules []string
+)
+
 // serialCmd represents the `serial` command
 var serialCmd = &cobra.Command{
 	Use:   "serial",
@@ -23,7 +31,25 @@ var serialCmd = &cobra.Command{
 
 // showSerial show serial information for the host system.
 func showSerial(cmd *cobra.Command, args []string) error {
-	serial, err := ghw.Serial()
+	opts := cmd.Context().Value(optsKey).([]ghw.Option)
+	if serialNaming != "" || len(serialNamingRules) > 0 {
+		policy := naming.Default()
+		if serialNaming != "" {
+			var err error
+			if policy, err = naming.FromString(serialNaming); err != nil {
+				return err
+			}
+		}
+		for _, r := range serialNamingRules {
+			rule, err := naming.RuleFromString(r)
+			if err != nil {
+				return err
+			}
+			policy.Rules = append(policy.Rules, *rule)
+		}
+		opts = append(opts, ghw.WithSerialNaming(policy))
+	}
+	serial, err := ghw.Serial(opts...)
 	if err != nil {
 		return errors.Wrap(err, "error getting serial info")
 	}
@@ -43,5 +69,13 @@ func showSerial(cmd *cobra.Command, args []string) error {
 }
 
 func init() {
+	serialCmd.Flags().StringVar(
+		&serialNaming, "naming", "",
+		"Comma-separated sources to name serial ports from, by order of preference: rules, io-base, acpi-uid, location or sequential",
+	)
+	serialCmd.Flags().StringArrayVar(
+		&serialNamingRules, "naming-rule", nil,
+		"Rule naming the serial port matching all its keys, for the rules source, e.g. console=io:0x3f8 or plc=path:pci0000:00/0000:00:14.0/usb1/1-2 (repeatable)",
+	)
 	rootCmd.AddCommand(serialCmd)
 }
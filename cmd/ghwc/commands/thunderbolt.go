@@ -0,0 +1,55 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/zededa/ghw"
+)
+
+// thunderboltCmd represents the `thunderbolt` command
+var thunderboltCmd = &cobra.Command{
+	Use:   "thunderbolt",
+	Short: "Show Thunderbolt and USB4 information for the host system",
+	RunE:  showThunderbolt,
+}
+
+// showThunderbolt shows the Thunderbolt domains, routers and PCIe tunnels of
+// the host system.
+func showThunderbolt(cmd *cobra.Command, args []string) error {
+	opts := cmd.Context().Value(optsKey).([]ghw.Option)
+	thunderbolt, err := ghw.Thunderbolt(opts...)
+	if err != nil {
+		return errors.Wrap(err, "error getting Thunderbolt info")
+	}
+
+	switch outputFormat {
+	case outputFormatHuman:
+		fmt.Printf("%v\n", thunderbolt)
+		for _, domain := range thunderbolt.Domains {
+			fmt.Printf(" %v\n", domain)
+			for _, router := range domain.Routers {
+				fmt.Printf("  %v\n", router)
+			}
+			for _, tunnel := range domain.Tunnels {
+				fmt.Printf("  PCIe port %s: %v\n", tunnel.Port, tunnel.Devices)
+			}
+		}
+	case outputFormatJSON:
+		fmt.Printf("%s\n", thunderbolt.JSONString(pretty))
+	case outputFormatYAML:
+		fmt.Printf("%s", thunderbolt.YAMLString())
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(thunderboltCmd)
+}
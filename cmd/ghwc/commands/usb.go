@@ -14,6 +14,11 @@ import (
 	"github.com/zededa/ghw"
 )
 
+var (
+	// show the USB topology instead of the device list
+	usbTree bool
+)
+
 // usbCmd represents the `usb` command
 var usbCmd = &cobra.Command{
 	Use:   "usb",
@@ -29,11 +34,19 @@ func showUSB(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "error getting network info")
 	}
 
+	if usbTree {
+		printInfo(usb.Tree())
+		return nil
+	}
+
 	switch outputFormat {
 	case outputFormatHuman:
 		fmt.Printf("%v\n", usb)
 		for _, usb := range usb.Devices {
 			fmt.Printf(" %+v\n", usb)
+			for _, iface := range usb.Interfaces {
+				fmt.Printf("  %v\n", iface)
+			}
 		}
 	case outputFormatJSON:
 		fmt.Printf("%s\n", usb.JSONString(pretty))
@@ -44,5 +57,8 @@ func showUSB(cmd *cobra.Command, args []string) error {
 }
 
 func init() {
+	usbCmd.Flags().BoolVar(
+		&usbTree, "tree", false, "Show the USB controllers, hubs and ports as a tree",
+	)
 	rootCmd.AddCommand(usbCmd)
 }
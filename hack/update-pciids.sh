@@ -0,0 +1,30 @@
+#!/usr/bin/env bash
+
+# Downloads the current pci.ids database of the PCI ID Project and stores it,
+# gzipped, as the database embedded by pkg/pci/pciids.
+
+set -euo pipefail
+
+PCIIDS_URL=${PCIIDS_URL:-https://pci-ids.ucw.cz/v2.2/pci.ids}
+
+root_dir=$(cd "$(dirname "$0")/.."; pwd)
+target=${1:-$root_dir/pkg/pci/pciids/pci.ids.gz}
+
+tmp_dir=$(mktemp -d -t ghw-pciids-XXX)
+trap 'rm -rf "$tmp_dir"' EXIT
+
+echo "downloading $PCIIDS_URL ..."
+curl -fsSL -o "$tmp_dir/pci.ids" "$PCIIDS_URL"
+
+# refuse to replace the embedded database with an error page
+if ! grep -q "^#[[:space:]]*Version:" "$tmp_dir/pci.ids"; then
+    echo "$PCIIDS_URL is not a pci.ids database"
+    exit 1
+fi
+grep -m1 "^#[[:space:]]*Version:" "$tmp_dir/pci.ids"
+
+# -n leaves out the file name and time, so the same database gives the same
+# file
+gzip -9 -n -c "$tmp_dir/pci.ids" > "$tmp_dir/pci.ids.gz"
+mv "$tmp_dir/pci.ids.gz" "$target"
+echo "wrote $target"
@@ -26,6 +26,7 @@ import (
 	"github.com/zededa/ghw/pkg/pci"
 	"github.com/zededa/ghw/pkg/product"
 	"github.com/zededa/ghw/pkg/serial"
+	"github.com/zededa/ghw/pkg/thunderbolt"
 	"github.com/zededa/ghw/pkg/topology"
 	"github.com/zededa/ghw/pkg/tpm"
 	"github.com/zededa/ghw/pkg/usb"
@@ -52,6 +53,7 @@ type HostInfo struct {
 	CAN         *can.Info         `json:"can"`
 	TPM         *tpm.Info         `json:"tpm"`
 	Watchdog    *watchdog.Info    `json:"watchdog"`
+	Thunderbolt *thunderbolt.Info `json:"thunderbolt"`
 	StatusLED   bool              `json:"status_led"`
 }
 
@@ -126,6 +128,10 @@ func Host(opts ...Option) (*HostInfo, error) {
 	if err != nil {
 		return nil, err
 	}
+	thunderboltInfo, err := thunderbolt.New(opts...)
+	if err != nil {
+		return nil, err
+	}
 
 	// Simple check for LEDs
 	statusLED := false
@@ -156,6 +162,7 @@ func Host(opts ...Option) (*HostInfo, error) {
 		CAN:         canInfo,
 		TPM:         tpmInfo,
 		Watchdog:    watchdogInfo,
+		Thunderbolt: thunderboltInfo,
 		StatusLED:   statusLED,
 	}, nil
 }
@@ -164,7 +171,7 @@ func Host(opts ...Option) (*HostInfo, error) {
 // structs' String-ified output
 func (info *HostInfo) String() string {
 	return fmt.Sprintf(
-		"%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\nStatusLED: %v\n",
+		"%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s\nStatusLED: %v\n",
 		info.Block.String(),
 		info.CPU.String(),
 		info.GPU.String(),
@@ -182,6 +189,7 @@ func (info *HostInfo) String() string {
 		info.CAN.String(),
 		info.TPM.String(),
 		info.Watchdog.String(),
+		info.Thunderbolt.String(),
 		info.StatusLED,
 	)
 }
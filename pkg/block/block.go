@@ -221,6 +221,9 @@ type Disk struct {
 	StorageController StorageController `json:"storage_controller"`
 	// BusPath is the filepath to the bus for this disk.
 	BusPath string `json:"bus_path"`
+	// USBAddress is a pointer to the address of the USB device backing this
+	// disk, e.g. "2-1", or nil if the disk is not a USB device.
+	USBAddress *string `json:"usb_address,omitempty"`
 	// NUMANodeID contains the numeric index (0-based) of the NUMA Node this
 	// disk is affined to, or -1 if the host system is non-NUMA.
 	// TODO(jaypipes): Convert this to a TopologyNode struct pointer and then
@@ -13,6 +13,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/zededa/ghw/pkg/bus"
 	"github.com/zededa/ghw/pkg/linuxpath"
 	"github.com/zededa/ghw/pkg/option"
 	"github.com/zededa/ghw/pkg/util"
@@ -182,6 +183,21 @@ func diskBusPath(paths *linuxpath.Paths, disk string) string {
 	return util.UNKNOWN
 }
 
+// diskUSBAddress returns the address of the USB device backing the disk, or
+// nil if the disk is not a USB device
+func diskUSBAddress(paths *linuxpath.Paths, disk string) *string {
+	devPath, err := filepath.EvalSymlinks(filepath.Join(paths.SysBlock, disk))
+	if err != nil {
+		return nil
+	}
+	parent := bus.ParentFromPath(devPath)
+	if parent.USB == nil {
+		return nil
+	}
+	usbAddr := parent.USB.String()
+	return &usbAddr
+}
+
 func diskWWNNoExtension(paths *linuxpath.Paths, disk string) string {
 	info, err := udevInfoDisk(paths, disk)
 	if err != nil {
@@ -344,6 +360,7 @@ func disks(opts *option.Options) []*Disk {
 		size := diskSizeBytes(paths, dname)
 		pbs := diskPhysicalBlockSizeBytes(paths, dname)
 		busPath := diskBusPath(paths, dname)
+		usbAddr := diskUSBAddress(paths, dname)
 		node := diskNUMANodeID(paths, dname)
 		vendor := diskVendor(paths, dname)
 		model := diskModel(paths, dname)
@@ -364,6 +381,7 @@ func disks(opts *option.Options) []*Disk {
 			IsRemovable:            removable,
 			StorageController:      storageController,
 			BusPath:                busPath,
+			USBAddress:             usbAddr,
 			NUMANodeID:             node,
 			Vendor:                 vendor,
 			Model:                  model,
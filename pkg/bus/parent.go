@@ -0,0 +1,23 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package bus
+
+import (
+	pciAddress "github.com/zededa/ghw/pkg/pci/address"
+	usbAddress "github.com/zededa/ghw/pkg/usb/address"
+)
+
+// ParentFromPath returns the PCI and USB devices the device at the given
+// sysfs path sits behind, e.g. PCI 0000:00:14.0 and USB 1-2 for
+// /sys/devices/pci0000:00/0000:00:14.0/usb1/1-2/1-2:1.0/ttyUSB0. Either is
+// nil if the device is not behind one.
+func ParentFromPath(path string) BusParent {
+	return BusParent{
+		PCI: pciAddress.FromPath(path),
+		USB: usbAddress.FromPath(path),
+	}
+}
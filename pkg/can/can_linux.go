@@ -8,10 +8,6 @@ import (
 	"github.com/zededa/ghw/pkg/bus"
 	"github.com/zededa/ghw/pkg/linuxpath"
 	"github.com/zededa/ghw/pkg/option"
-	"github.com/zededa/ghw/pkg/pci"
-	pciAddress "github.com/zededa/ghw/pkg/pci/address"
-	"github.com/zededa/ghw/pkg/usb"
-	usbAddress "github.com/zededa/ghw/pkg/usb/address"
 )
 
 func (i *Info) load(opts *option.Options) error {
@@ -43,29 +39,10 @@ func (i *Info) load(opts *option.Options) error {
 		// Resolve Parent (PCI/USB)
 		realPath, err := filepath.EvalSymlinks(filepath.Join(devPath, "device"))
 		if err == nil {
-			device.Parent = resolveParent(strings.TrimPrefix(realPath, paths.SysRoot))
+			device.Parent = bus.ParentFromPath(strings.TrimPrefix(realPath, paths.SysRoot))
 		}
 
 		i.Devices = append(i.Devices, device)
 	}
 	return nil
 }
-
-// resolveParent attempts to find PCI or USB parent info from a sysfs path
-func resolveParent(path string) bus.BusParent {
-	parent := bus.BusParent{}
-
-	bus, port, err := usb.ExtractUSBBusnumPort(path)
-	if err == nil {
-		parent.USB = &usbAddress.Address{
-			Busnum: bus,
-			Port:   port,
-		}
-	}
-	pciAddr := pci.FindPCIAddress(path)
-	if pciAddr != "" {
-		parent.PCI = pciAddress.FromString(pciAddr)
-	}
-
-	return parent
-}
@@ -0,0 +1,84 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// Package console tells which serial ports are kernel consoles, which the
+// host loses when they are given to a guest.
+package console
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Consoles are the kernel consoles, by tty name, by IO address or by MMIO
+// address.
+type Consoles struct {
+	ttys       map[string]bool
+	ioBases    map[uint64]bool
+	iomemBases map[uint64]bool
+}
+
+func newConsoles() *Consoles {
+	return &Consoles{
+		ttys:       map[string]bool{},
+		ioBases:    map[uint64]bool{},
+		iomemBases: map[uint64]bool{},
+	}
+}
+
+// Contains returns true if the port with the given tty name, IO address or
+// MMIO address is a kernel console. Addresses of 0 are ignored.
+func (c *Consoles) Contains(tty string, ioBase, iomemBase uint64) bool {
+	return c.ttys[tty] || (ioBase != 0 && c.ioBases[ioBase]) ||
+		(iomemBase != 0 && c.iomemBases[iomemBase])
+}
+
+// parseConsoles parses /proc/consoles, returning the tty of each console:
+//
+//	ttyS0                -W- (EC  p a)    4:64
+func parseConsoles(r io.Reader) []string {
+	var ttys []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		if fields := strings.Fields(sc.Text()); len(fields) > 0 {
+			ttys = append(ttys, fields[0])
+		}
+	}
+	return ttys
+}
+
+// addCmdline adds the consoles of the given kernel command line, either
+// given by tty, e.g. console=ttyS0,115200n8, or by address, e.g.
+// console=uart8250,io,0x3f8,115200n8 or console=uart,mmio32,0xfe215040
+func (c *Consoles) addCmdline(cmdline string) {
+	for _, param := range strings.Fields(cmdline) {
+		if param == "--" {
+			// the remaining parameters are for init
+			break
+		}
+		value, found := strings.CutPrefix(param, "console=")
+		if !found {
+			continue
+		}
+		options := strings.Split(value, ",")
+		if (options[0] == "uart" || options[0] == "uart8250") && len(options) > 2 {
+			base, err := strconv.ParseUint(options[2], 0, 64)
+			if err != nil {
+				continue
+			}
+			switch options[1] {
+			case "io":
+				c.ioBases[base] = true
+			case "mmio", "mmio16", "mmio32", "mmio32be":
+				c.iomemBases[base] = true
+			}
+			continue
+		}
+		c.ttys[options[0]] = true
+	}
+}
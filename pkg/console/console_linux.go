@@ -0,0 +1,73 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package console
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/zededa/ghw/pkg/linuxpath"
+)
+
+// New returns the kernel consoles listed in /proc/consoles, in
+// /sys/class/tty/console/active and in the console= parameters of the kernel
+// command line.
+func New(paths *linuxpath.Paths) *Consoles {
+	c := newConsoles()
+	if f, err := os.Open(paths.ProcConsoles); err == nil {
+		for _, tty := range parseConsoles(f) {
+			c.ttys[tty] = true
+		}
+		f.Close()
+	}
+	if active, err := os.ReadFile(filepath.Join(paths.SysClassTty, "console", "active")); err == nil {
+		for _, tty := range strings.Fields(string(active)) {
+			c.ttys[tty] = true
+		}
+	}
+	if cmdline, err := os.ReadFile(paths.ProcCmdline); err == nil {
+		c.addCmdline(string(cmdline))
+	}
+	return c
+}
+
+// IsConsole returns true if the given tty, e.g. "ttyS0", is a kernel
+// console: either the kernel flags it as such in sysfs or it matches one of
+// the consoles by name or by address.
+func (c *Consoles) IsConsole(paths *linuxpath.Paths, tty string) bool {
+	ttyDir := filepath.Join(paths.SysClassTty, tty)
+	if slurp(filepath.Join(ttyDir, "console")) == "Y" {
+		return true
+	}
+	ioBase, iomemBase := uint64(0), uint64(0)
+	// io_type 0 is UPIO_PORT, the others are memory mapped
+	if slurp(filepath.Join(ttyDir, "io_type")) == "0" {
+		ioBase = readHex(filepath.Join(ttyDir, "port"))
+	} else {
+		iomemBase = readHex(filepath.Join(ttyDir, "iomem_base"))
+	}
+	return c.Contains(tty, ioBase, iomemBase)
+}
+
+func slurp(path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// readHex returns the hexadecimal number in the given file, e.g. "0x3F8", 0
+// if none
+func readHex(path string) uint64 {
+	v, err := strconv.ParseUint(strings.TrimPrefix(slurp(path), "0x"), 16, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
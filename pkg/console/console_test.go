@@ -0,0 +1,40 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package console
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConsoles(t *testing.T) {
+	c := newConsoles()
+	for _, tty := range parseConsoles(strings.NewReader("ttyAMA0              -W- (EC  p a)  204:64\n")) {
+		c.ttys[tty] = true
+	}
+	c.addCmdline("root=/dev/sda1 console=uart8250,io,0x2f8,115200n8 console=uart,mmio32,0xfe215040 console=tty1 -- console=ttyS0")
+
+	tests := []struct {
+		tty       string
+		ioBase    uint64
+		iomemBase uint64
+		expected  bool
+	}{
+		{"ttyAMA0", 0, 0x9000000, true},
+		{"tty1", 0, 0, true},
+		{"ttyS1", 0x2f8, 0, true},
+		{"ttyS2", 0, 0xfe215040, true},
+		// console=ttyS0 comes after "--", it is for init
+		{"ttyS0", 0x3f8, 0, false},
+		{"ttyS3", 0, 0, false},
+	}
+	for _, test := range tests {
+		if got := c.Contains(test.tty, test.ioBase, test.iomemBase); got != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.tty, test.expected, got)
+		}
+	}
+}
@@ -56,24 +56,36 @@ func PathRootsFromContext(opts *option.Options) PathRoots {
 }
 
 type Paths struct {
-	SysRoot                string
-	VarLog                 string
-	ProcMeminfo            string
-	ProcCpuinfo            string
-	ProcMounts             string
-	SysKernelMMHugepages   string
-	SysBlock               string
-	SysDevicesSystemNode   string
-	SysDevicesSystemMemory string
-	SysDevicesSystemCPU    string
-	SysBusPciDevices       string
-	SysBusUsbDevices       string
-	SysClassDRM            string
-	SysClassDMI            string
-	SysClassNet            string
-	SysClassTty            string
-	SysClassTpm            string
-	RunUdevData            string
+	SysRoot                  string
+	VarLog                   string
+	ProcMeminfo              string
+	ProcCpuinfo              string
+	ProcMounts               string
+	ProcInterrupts           string
+	ProcIrq                  string
+	ProcCmdline              string
+	ProcConsoles             string
+	ProcTtyDrivers           string
+	ProcTtyDriver            string
+	SysKernelMMHugepages     string
+	SysBlock                 string
+	SysDevicesSystemNode     string
+	SysDevicesSystemMemory   string
+	SysDevicesSystemCPU      string
+	SysBusPciDevices         string
+	SysBusPciDrivers         string
+	SysBusPciDriversProbe    string
+	SysBusPciSlots           string
+	SysBusUsbDevices         string
+	SysBusThunderboltDevices string
+	SysClass                 string
+	SysClassDRM              string
+	SysClassDMI              string
+	SysClassNet              string
+	SysClassTty              string
+	SysClassTpm              string
+	SysFirmwareDMIEntries    string
+	RunUdevData              string
 }
 
 // New returns a new Paths struct containing filepath fields relative to the
@@ -81,24 +93,36 @@ type Paths struct {
 func New(opts *option.Options) *Paths {
 	roots := PathRootsFromContext(opts)
 	return &Paths{
-		SysRoot:                filepath.Join(opts.Chroot, roots.Sys),
-		VarLog:                 filepath.Join(opts.Chroot, roots.Var, "log"),
-		ProcMeminfo:            filepath.Join(opts.Chroot, roots.Proc, "meminfo"),
-		ProcCpuinfo:            filepath.Join(opts.Chroot, roots.Proc, "cpuinfo"),
-		ProcMounts:             filepath.Join(opts.Chroot, roots.Proc, "self", "mounts"),
-		SysKernelMMHugepages:   filepath.Join(opts.Chroot, roots.Sys, "kernel", "mm", "hugepages"),
-		SysBlock:               filepath.Join(opts.Chroot, roots.Sys, "block"),
-		SysDevicesSystemNode:   filepath.Join(opts.Chroot, roots.Sys, "devices", "system", "node"),
-		SysDevicesSystemMemory: filepath.Join(opts.Chroot, roots.Sys, "devices", "system", "memory"),
-		SysDevicesSystemCPU:    filepath.Join(opts.Chroot, roots.Sys, "devices", "system", "cpu"),
-		SysBusPciDevices:       filepath.Join(opts.Chroot, roots.Sys, "bus", "pci", "devices"),
-		SysBusUsbDevices:       filepath.Join(opts.Chroot, roots.Sys, "bus", "usb", "devices"),
-		SysClassDRM:            filepath.Join(opts.Chroot, roots.Sys, "class", "drm"),
-		SysClassDMI:            filepath.Join(opts.Chroot, roots.Sys, "class", "dmi"),
-		SysClassNet:            filepath.Join(opts.Chroot, roots.Sys, "class", "net"),
-		SysClassTty:            filepath.Join(opts.Chroot, roots.Sys, "class", "tty"),
-		SysClassTpm:            filepath.Join(opts.Chroot, roots.Sys, "class", "tpm"),
-		RunUdevData:            filepath.Join(opts.Chroot, roots.Run, "udev", "data"),
+		SysRoot:                  filepath.Join(opts.Chroot, roots.Sys),
+		VarLog:                   filepath.Join(opts.Chroot, roots.Var, "log"),
+		ProcMeminfo:              filepath.Join(opts.Chroot, roots.Proc, "meminfo"),
+		ProcCpuinfo:              filepath.Join(opts.Chroot, roots.Proc, "cpuinfo"),
+		ProcMounts:               filepath.Join(opts.Chroot, roots.Proc, "self", "mounts"),
+		ProcInterrupts:           filepath.Join(opts.Chroot, roots.Proc, "interrupts"),
+		ProcIrq:                  filepath.Join(opts.Chroot, roots.Proc, "irq"),
+		ProcCmdline:              filepath.Join(opts.Chroot, roots.Proc, "cmdline"),
+		ProcConsoles:             filepath.Join(opts.Chroot, roots.Proc, "consoles"),
+		ProcTtyDrivers:           filepath.Join(opts.Chroot, roots.Proc, "tty", "drivers"),
+		ProcTtyDriver:            filepath.Join(opts.Chroot, roots.Proc, "tty", "driver"),
+		SysKernelMMHugepages:     filepath.Join(opts.Chroot, roots.Sys, "kernel", "mm", "hugepages"),
+		SysBlock:                 filepath.Join(opts.Chroot, roots.Sys, "block"),
+		SysDevicesSystemNode:     filepath.Join(opts.Chroot, roots.Sys, "devices", "system", "node"),
+		SysDevicesSystemMemory:   filepath.Join(opts.Chroot, roots.Sys, "devices", "system", "memory"),
+		SysDevicesSystemCPU:      filepath.Join(opts.Chroot, roots.Sys, "devices", "system", "cpu"),
+		SysBusPciDevices:         filepath.Join(opts.Chroot, roots.Sys, "bus", "pci", "devices"),
+		SysBusPciDrivers:         filepath.Join(opts.Chroot, roots.Sys, "bus", "pci", "drivers"),
+		SysBusPciDriversProbe:    filepath.Join(opts.Chroot, roots.Sys, "bus", "pci", "drivers_probe"),
+		SysBusPciSlots:           filepath.Join(opts.Chroot, roots.Sys, "bus", "pci", "slots"),
+		SysBusUsbDevices:         filepath.Join(opts.Chroot, roots.Sys, "bus", "usb", "devices"),
+		SysBusThunderboltDevices: filepath.Join(opts.Chroot, roots.Sys, "bus", "thunderbolt", "devices"),
+		SysClass:                 filepath.Join(opts.Chroot, roots.Sys, "class"),
+		SysClassDRM:              filepath.Join(opts.Chroot, roots.Sys, "class", "drm"),
+		SysClassDMI:              filepath.Join(opts.Chroot, roots.Sys, "class", "dmi"),
+		SysClassNet:              filepath.Join(opts.Chroot, roots.Sys, "class", "net"),
+		SysClassTty:              filepath.Join(opts.Chroot, roots.Sys, "class", "tty"),
+		SysClassTpm:              filepath.Join(opts.Chroot, roots.Sys, "class", "tpm"),
+		SysFirmwareDMIEntries:    filepath.Join(opts.Chroot, roots.Sys, "firmware", "dmi", "entries"),
+		RunUdevData:              filepath.Join(opts.Chroot, roots.Run, "udev", "data"),
 	}
 }
 
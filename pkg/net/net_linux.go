@@ -14,11 +14,9 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/zededa/ghw/pkg/bus"
 	"github.com/zededa/ghw/pkg/linuxpath"
 	"github.com/zededa/ghw/pkg/option"
-	"github.com/zededa/ghw/pkg/pci"
-	"github.com/zededa/ghw/pkg/usb"
-	usbAddress "github.com/zededa/ghw/pkg/usb/address"
 	"github.com/zededa/ghw/pkg/util"
 )
 
@@ -79,21 +77,14 @@ func nics(opts *option.Options) []*NIC {
 		}
 
 		devPath := netDeviceDevPath(paths.SysClassNet, filename)
-		pciAddr := pci.FindPCIAddress(devPath)
-		if pciAddr != "" {
+		parent := bus.ParentFromPath(devPath)
+		if parent.PCI != nil {
+			pciAddr := parent.PCI.String()
 			nic.PCIAddress = &pciAddr
 		}
-		busnum, port, err := usb.ExtractUSBBusnumPort(devPath)
-		if err == nil {
-			addr := usbAddress.Address{
-				Busnum: busnum,
-				Port:   port,
-			}
-
-			usbAddr := addr.String()
-			if usbAddr != "" {
-				nic.USBAddress = &usbAddr
-			}
+		if parent.USB != nil {
+			usbAddr := parent.USB.String()
+			nic.USBAddress = &usbAddr
 		}
 
 		nics = append(nics, nic)
@@ -108,8 +108,28 @@ type Options struct {
 	// database automatically.
 	PCIDB *pcidb.PCIDB
 
+	// EmbeddedPCIDB allows ghw to fall back to the compressed copy of the
+	// PCI database embedded in the binary when no pci.ids file is found on
+	// the host, instead of failing. See WithEmbeddedPCIDB.
+	EmbeddedPCIDB bool
+
+	// PCIVPD makes ghw read the Vital Product Data of the PCI devices, which
+	// is slow, and may even stall, on some devices. See WithPCIVPD.
+	PCIVPD bool
+
+	// USBDB allows users to provide a custom instance of the USB ID database
+	// to be used by ghw, instead of letting ghw load it automatically. It
+	// holds the *usbdb.DB given to usb.WithUSBDB, opaque to this package so
+	// that it does not depend on the USB one.
+	USBDB interface{}
+
 	// Filter USB devices by uevent file path in sysfs
 	USBUeventPath string
+
+	// SerialNaming tells how to name serial ports, naming.Default() if nil.
+	// It holds the *naming.Policy given to serial.WithNaming, opaque to this
+	// package so that it does not depend on the serial one.
+	SerialNaming interface{}
 }
 
 func (o *Options) Warn(msg string, args ...interface{}) {
@@ -159,6 +179,26 @@ func WithPCIDB(pcidb *pcidb.PCIDB) Option {
 	}
 }
 
+// WithEmbeddedPCIDB allows ghw to fall back to the PCI database embedded in
+// the binary when the host ships no pci.ids file, as is common on minimal
+// images. The embedded database may be older than the host one. It is only
+// linked into the binaries importing github.com/zededa/ghw/pkg/pci/pciids.
+func WithEmbeddedPCIDB() Option {
+	return func(opts *Options) {
+		opts.EmbeddedPCIDB = true
+	}
+}
+
+// WithPCIVPD makes ghw read the Vital Product Data of the PCI devices, such
+// as their board serial numbers. It is off by default: the kernel reads VPD
+// through the configuration space a few bytes at a time, which takes seconds
+// on some devices and usually requires root privileges.
+func WithPCIVPD() Option {
+	return func(opts *Options) {
+		opts.PCIVPD = true
+	}
+}
+
 func WithUSBUeventPath(path string) Option {
 	return func(opts *Options) {
 		opts.USBUeventPath = path
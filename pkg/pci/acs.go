@@ -0,0 +1,231 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"fmt"
+	"strings"
+
+	pciaddr "github.com/zededa/ghw/pkg/pci/address"
+)
+
+// The ACS features which must be enabled on a port to make sure requests
+// from the devices below it are sent upstream to the IOMMU instead of being
+// routed peer-to-peer. Same as the kernel's REQ_ACS_FLAGS.
+const acsRequiredFlags = ACSSourceValidation | ACSRequestRedirect | ACSCompletionRedirect | ACSUpstreamForwarding
+
+var acsFlagNames = []struct {
+	flag uint16
+	name string
+}{
+	{ACSSourceValidation, "SV"},
+	{ACSTranslationBlocking, "TB"},
+	{ACSRequestRedirect, "RR"},
+	{ACSCompletionRedirect, "CR"},
+	{ACSUpstreamForwarding, "UF"},
+	{ACSEgressControl, "EC"},
+	{ACSDirectTranslatedP2P, "DT"},
+}
+
+// ACSHopStatus tells whether a device on the path to the root complex
+// prevents peer-to-peer traffic from bypassing the IOMMU.
+type ACSHopStatus string
+
+const (
+	// ACSHopIsolating indicates the device enables all the required ACS
+	// features
+	ACSHopIsolating ACSHopStatus = "isolating"
+	// ACSHopNotIsolating indicates the device lets peer-to-peer traffic
+	// through without sending it to the IOMMU
+	ACSHopNotIsolating ACSHopStatus = "not-isolating"
+	// ACSHopNotRequired indicates the device type does not route
+	// peer-to-peer traffic, e.g. switch upstream ports
+	ACSHopNotRequired ACSHopStatus = "not-required"
+	// ACSHopUnknown indicates the device configuration space could not be
+	// read, which usually means ghw is not running as root
+	ACSHopUnknown ACSHopStatus = "unknown"
+)
+
+// ACSVerdict tells whether peer-to-peer traffic from a device can bypass the
+// IOMMU.
+type ACSVerdict string
+
+const (
+	// ACSVerdictIsolated indicates all the traffic of the device reaches the
+	// IOMMU
+	ACSVerdictIsolated ACSVerdict = "isolated"
+	// ACSVerdictPeerToPeer indicates the device can reach other devices
+	// without going through the IOMMU, hence it shares an IOMMU group with
+	// them
+	ACSVerdictPeerToPeer ACSVerdict = "peer-to-peer"
+	// ACSVerdictUnknown indicates the configuration space of some device on
+	// the path could not be read
+	ACSVerdictUnknown ACSVerdict = "unknown"
+)
+
+// ACSHop describes the ACS state of a device on the path from a PCI device
+// to the root complex.
+type ACSHop struct {
+	Address string       `json:"address"`
+	Status  ACSHopStatus `json:"status"`
+	// Human-readable explanation of the status
+	Reason string `json:"reason"`
+	// Bitmasks of the supported and enabled ACS features (ACS* constants).
+	// Both are 0 if the device lacks the ACS capability.
+	Capability uint16 `json:"capability"`
+	Control    uint16 `json:"control"`
+}
+
+func (h ACSHop) String() string {
+	return fmt.Sprintf("%s: %s (%s)", h.Address, h.Status, h.Reason)
+}
+
+// ACSPath describes the ACS isolation of a PCI device along its path to the
+// root complex.
+type ACSPath struct {
+	// The PCI address of the device the path starts from
+	Address string `json:"address"`
+	// The device itself followed by its upstream bridges and ports, up to
+	// the root port
+	Hops    []ACSHop   `json:"hops"`
+	Verdict ACSVerdict `json:"verdict"`
+}
+
+func (p *ACSPath) String() string {
+	hops := make([]string, 0, len(p.Hops))
+	for _, hop := range p.Hops {
+		hops = append(hops, hop.String())
+	}
+	return fmt.Sprintf("%s ACS %s [%s]", p.Address, p.Verdict, strings.Join(hops, " -> "))
+}
+
+// ACSIsolation walks from the device at the given address up to the root
+// complex, reporting for each bridge or port on the way whether ACS keeps
+// peer-to-peer traffic from bypassing the IOMMU. Devices not isolated this
+// way end up in the same IOMMU group. Returns nil if no such device exists.
+func (info *Info) ACSIsolation(address string) *ACSPath {
+	dev := info.lookupDevice(address)
+	if dev == nil {
+		return nil
+	}
+	path := &ACSPath{
+		Address: address,
+		Verdict: ACSVerdictIsolated,
+	}
+	// guard against loops in made-up or corrupted data
+	seen := map[string]bool{}
+	for first := true; dev != nil && !seen[dev.Address]; first = false {
+		seen[dev.Address] = true
+		hop := info.acsHop(dev, first)
+		switch hop.Status {
+		case ACSHopNotIsolating:
+			path.Verdict = ACSVerdictPeerToPeer
+		case ACSHopUnknown:
+			if path.Verdict == ACSVerdictIsolated {
+				path.Verdict = ACSVerdictUnknown
+			}
+		}
+		path.Hops = append(path.Hops, hop)
+		dev = info.lookupDevice(dev.ParentAddress)
+	}
+	return path
+}
+
+// acsHop evaluates a single device of the path. Mirrors the kernel's
+// pci_acs_enabled(): only downstream ports, root ports and, for the device
+// the path starts from, multi-function endpoints need to enable ACS.
+func (info *Info) acsHop(dev *Device, first bool) ACSHop {
+	hop := ACSHop{Address: dev.Address}
+	caps := dev.Capabilities
+	if caps == nil || caps.ConfigSize <= configExtCapStart {
+		hop.Status = ACSHopUnknown
+		hop.Reason = "extended configuration space not readable"
+		return hop
+	}
+	if caps.ACS != nil {
+		hop.Capability = caps.ACS.Capability
+		hop.Control = caps.ACS.Control
+	}
+	if caps.Express == nil {
+		if !dev.IsBridge() {
+			hop.Status = ACSHopNotRequired
+			hop.Reason = "conventional PCI device"
+			return hop
+		}
+		hop.Status = ACSHopNotIsolating
+		hop.Reason = "conventional PCI bridge"
+		return hop
+	}
+
+	switch caps.Express.PortType {
+	case ExpressPortTypeDownstreamPort, ExpressPortTypeRootPort:
+	case ExpressPortTypePCIeToPCIBridge:
+		hop.Status = ACSHopNotIsolating
+		hop.Reason = "bridge to conventional PCI"
+		return hop
+	case ExpressPortTypeEndpoint, ExpressPortTypeLegacyEndpoint, ExpressPortTypeRCIntegratedEP:
+		if !first || !info.isMultiFunction(dev) {
+			hop.Status = ACSHopNotRequired
+			hop.Reason = "single function " + caps.Express.PortType.String()
+			return hop
+		}
+	default:
+		hop.Status = ACSHopNotRequired
+		hop.Reason = caps.Express.PortType.String()
+		return hop
+	}
+
+	if caps.ACS == nil {
+		hop.Status = ACSHopNotIsolating
+		hop.Reason = "ACS not supported"
+		return hop
+	}
+	// features missing from the capability register are hardwired enabled,
+	// except for egress control
+	required := uint16(acsRequiredFlags) & (hop.Capability | ACSEgressControl)
+	if missing := required &^ hop.Control; missing != 0 {
+		hop.Status = ACSHopNotIsolating
+		hop.Reason = "ACS " + acsFlagsString(missing) + " disabled"
+		return hop
+	}
+	hop.Status = ACSHopIsolating
+	hop.Reason = "ACS " + acsFlagsString(hop.Control) + " enabled"
+	return hop
+}
+
+// isMultiFunction returns true if other functions share the PCI device
+// number of the given device.
+func (info *Info) isMultiFunction(dev *Device) bool {
+	addr := pciaddr.FromString(dev.Address)
+	if addr == nil {
+		return false
+	}
+	for _, other := range info.Devices {
+		if other.Address == dev.Address {
+			continue
+		}
+		otherAddr := pciaddr.FromString(other.Address)
+		if otherAddr != nil && otherAddr.Domain == addr.Domain &&
+			otherAddr.Bus == addr.Bus && otherAddr.Device == addr.Device {
+			return true
+		}
+	}
+	return false
+}
+
+func acsFlagsString(flags uint16) string {
+	names := []string{}
+	for _, f := range acsFlagNames {
+		if flags&f.flag != 0 {
+			names = append(names, f.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,160 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zededa/ghw/pkg/pci"
+)
+
+// acsTestCapabilities returns the capabilities of a PCIe device of the given
+// port type, with the ACS capability if acsCap is not zero.
+func acsTestCapabilities(portType uint16, acsCap, acsCtrl uint16) *pci.Capabilities {
+	b := make(configBlob, 4096)
+	b.put16(0x06, 0x0010)
+	b[0x34] = 0x40
+	b[0x40] = 0x10
+	b.put16(0x42, 0x0002|portType<<4)
+	if acsCap != 0 {
+		b.putExtHeader(0x100, 0x000d, 1, 0)
+		b.put16(0x104, acsCap)
+		b.put16(0x106, acsCtrl)
+	}
+	return pci.ParseConfigSpace(b)
+}
+
+func TestACSIsolation(t *testing.T) {
+	info := &pci.Info{
+		Devices: []*pci.Device{
+			{
+				Address:      "0000:00:1c.0",
+				Capabilities: acsTestCapabilities(0x4, 0x005f, 0x001d),
+			},
+			{
+				Address:       "0000:01:00.0",
+				ParentAddress: "0000:00:1c.0",
+				Capabilities:  acsTestCapabilities(0x5, 0, 0),
+			},
+			{
+				Address:       "0000:02:01.0",
+				ParentAddress: "0000:01:00.0",
+				Capabilities:  acsTestCapabilities(0x6, 0, 0),
+			},
+			{
+				Address:       "0000:02:02.0",
+				ParentAddress: "0000:01:00.0",
+				Capabilities:  acsTestCapabilities(0x6, 0x001f, 0x0001),
+			},
+			{
+				Address:       "0000:03:00.0",
+				ParentAddress: "0000:02:01.0",
+				Capabilities:  acsTestCapabilities(0x0, 0, 0),
+			},
+			{
+				Address:       "0000:04:00.0",
+				ParentAddress: "0000:02:02.0",
+				Capabilities:  acsTestCapabilities(0x0, 0, 0),
+			},
+			{
+				Address:       "0000:05:00.0",
+				ParentAddress: "0000:01:00.0",
+				Capabilities:  acsTestCapabilities(0x0, 0, 0),
+			},
+			{
+				Address:       "0000:05:00.1",
+				ParentAddress: "0000:01:00.0",
+				Capabilities:  acsTestCapabilities(0x0, 0, 0),
+			},
+			{
+				Address:      "0000:00:1f.0",
+				Capabilities: pci.ParseConfigSpace(make([]byte, 64)),
+			},
+		},
+	}
+
+	tCases := []struct {
+		addr     string
+		verdict  pci.ACSVerdict
+		statuses []pci.ACSHopStatus
+	}{
+		{
+			addr:    "0000:03:00.0",
+			verdict: pci.ACSVerdictPeerToPeer,
+			statuses: []pci.ACSHopStatus{
+				pci.ACSHopNotRequired,
+				pci.ACSHopNotIsolating,
+				pci.ACSHopNotRequired,
+				pci.ACSHopIsolating,
+			},
+		},
+		{
+			addr:    "0000:04:00.0",
+			verdict: pci.ACSVerdictPeerToPeer,
+			statuses: []pci.ACSHopStatus{
+				pci.ACSHopNotRequired,
+				pci.ACSHopNotIsolating,
+				pci.ACSHopNotRequired,
+				pci.ACSHopIsolating,
+			},
+		},
+		{
+			addr:    "0000:01:00.0",
+			verdict: pci.ACSVerdictIsolated,
+			statuses: []pci.ACSHopStatus{
+				pci.ACSHopNotRequired,
+				pci.ACSHopIsolating,
+			},
+		},
+		{
+			// multi-function endpoint without ACS
+			addr:    "0000:05:00.1",
+			verdict: pci.ACSVerdictPeerToPeer,
+			statuses: []pci.ACSHopStatus{
+				pci.ACSHopNotIsolating,
+				pci.ACSHopNotRequired,
+				pci.ACSHopIsolating,
+			},
+		},
+		{
+			addr:    "0000:00:1f.0",
+			verdict: pci.ACSVerdictUnknown,
+			statuses: []pci.ACSHopStatus{
+				pci.ACSHopUnknown,
+			},
+		},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.addr, func(t *testing.T) {
+			path := info.ACSIsolation(tCase.addr)
+			if path == nil {
+				t.Fatalf("got nil ACS path for %q", tCase.addr)
+			}
+			statuses := []pci.ACSHopStatus{}
+			for _, hop := range path.Hops {
+				statuses = append(statuses, hop.Status)
+			}
+			if !reflect.DeepEqual(statuses, tCase.statuses) {
+				t.Errorf("got hops %v expected statuses %v", path.Hops, tCase.statuses)
+			}
+			if path.Verdict != tCase.verdict {
+				t.Errorf("got verdict %q expected %q", path.Verdict, tCase.verdict)
+			}
+		})
+	}
+
+	if path := info.ACSIsolation("0000:00:1c.0"); path.Hops[0].Reason != "ACS SV,RR,CR,UF enabled" {
+		t.Errorf("unexpected root port reason %q", path.Hops[0].Reason)
+	}
+	if path := info.ACSIsolation("0000:04:00.0"); path.Hops[1].Reason != "ACS RR,CR,UF disabled" {
+		t.Errorf("unexpected downstream port reason %q", path.Hops[1].Reason)
+	}
+	if path := info.ACSIsolation("0000:ff:00.0"); path != nil {
+		t.Errorf("expected nil ACS path for unknown device, got %v", path)
+	}
+}
@@ -7,6 +7,7 @@
 package address
 
 import (
+	"path/filepath"
 	"regexp"
 	"strings"
 )
@@ -52,3 +53,23 @@ func FromString(address string) *Address {
 	}
 	return nil
 }
+
+// FromPath returns the [Address] of the deepest PCI device in a sysfs path,
+// e.g. 0000:03:00.0 for
+// /sys/devices/pci0000:00/0000:00:1c.0/0000:03:00.0/usb1/1-2, i.e. the PCI
+// device the device at the path sits behind.
+//
+// If the path holds no PCI address, then nil is returned.
+func FromPath(path string) *Address {
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	for idx := len(parts) - 1; idx >= 0; idx-- {
+		// only match full addresses, which include the domain
+		if strings.Count(parts[idx], ":") != 2 {
+			continue
+		}
+		if addr := FromString(parts[idx]); addr != nil {
+			return addr
+		}
+	}
+	return nil
+}
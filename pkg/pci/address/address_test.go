@@ -90,3 +90,39 @@ func TestPCIAddressFromString(t *testing.T) {
 		}
 	}
 }
+
+func TestPCIAddressFromPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected *pciaddr.Address
+	}{
+		{
+			path: "/sys/devices/pci0000:00/0000:00:1c.0/0000:03:00.0/usb1/1-2/1-2:1.0",
+			expected: &pciaddr.Address{
+				Domain:   "0000",
+				Bus:      "03",
+				Device:   "00",
+				Function: "0",
+			},
+		},
+		{
+			path: "/sys/devices/pci0000:00/0000:00:1f.6/net/enp0s31f6",
+			expected: &pciaddr.Address{
+				Domain:   "0000",
+				Bus:      "00",
+				Device:   "1f",
+				Function: "6",
+			},
+		},
+		{
+			path:     "/sys/devices/platform/serial8250/tty/ttyS1",
+			expected: nil,
+		},
+	}
+	for x, test := range tests {
+		got := pciaddr.FromPath(test.path)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Fatalf("Test #%d failed. Expected %v but got %v", x, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AERCounters holds the Advanced Error Reporting counters of one severity.
+type AERCounters struct {
+	// The sum of all the errors, as reported by the kernel in the
+	// TOTAL_ERR_* line
+	Total uint64 `json:"total"`
+	// The number of errors of each type, keyed by the name the kernel gives
+	// the error, e.g. "BadTLP", "RxErr" or "CmpltTO"
+	Errors map[string]uint64 `json:"errors"`
+}
+
+// AERRootPortStats holds the number of error messages a root port received
+// from the devices below it.
+type AERRootPortStats struct {
+	TotalCorrectable uint64 `json:"total_correctable"`
+	TotalNonFatal    uint64 `json:"total_nonfatal"`
+	TotalFatal       uint64 `json:"total_fatal"`
+}
+
+// AERStats holds the PCI Express Advanced Error Reporting counters of a
+// device. Growing correctable error counters are a common sign of a marginal
+// link.
+type AERStats struct {
+	Correctable *AERCounters `json:"correctable,omitempty"`
+	NonFatal    *AERCounters `json:"nonfatal,omitempty"`
+	Fatal       *AERCounters `json:"fatal,omitempty"`
+	// Only set for root ports and root complex event collectors
+	RootPort *AERRootPortStats `json:"root_port,omitempty"`
+}
+
+func (s *AERStats) String() string {
+	return fmt.Sprintf(
+		"AER correctable: %d nonfatal: %d fatal: %d",
+		s.Correctable.total(),
+		s.NonFatal.total(),
+		s.Fatal.total(),
+	)
+}
+
+// HasErrors returns true if the device reported any error, of any severity.
+func (s *AERStats) HasErrors() bool {
+	return s.Correctable.total() > 0 || s.NonFatal.total() > 0 || s.Fatal.total() > 0
+}
+
+func (c *AERCounters) total() uint64 {
+	if c == nil {
+		return 0
+	}
+	return c.Total
+}
+
+// the prefix of the line of the aer_dev_* files holding the total count
+const aerTotalPrefix = "TOTAL_ERR_"
+
+// parseAERCounters parses the content of an aer_dev_correctable,
+// aer_dev_nonfatal or aer_dev_fatal file, made of "<name> <count>" lines.
+func parseAERCounters(data []byte) *AERCounters {
+	counters := &AERCounters{Errors: map[string]uint64{}}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		count, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if strings.HasPrefix(fields[0], aerTotalPrefix) {
+			counters.Total = count
+			continue
+		}
+		counters.Errors[fields[0]] = count
+	}
+	return counters
+}
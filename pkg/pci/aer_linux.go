@@ -0,0 +1,57 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/zededa/ghw/pkg/linuxpath"
+	pciaddr "github.com/zededa/ghw/pkg/pci/address"
+)
+
+// getDeviceAER returns the AER counters of the device at the given address,
+// or nil if the kernel does not report AER for the device.
+func getDeviceAER(paths *linuxpath.Paths, pciAddr *pciaddr.Address) *AERStats {
+	devPath := filepath.Join(paths.SysBusPciDevices, pciAddr.String())
+
+	stats := &AERStats{
+		Correctable: readAERCounters(filepath.Join(devPath, "aer_dev_correctable")),
+		NonFatal:    readAERCounters(filepath.Join(devPath, "aer_dev_nonfatal")),
+		Fatal:       readAERCounters(filepath.Join(devPath, "aer_dev_fatal")),
+	}
+	cor, okCor := readAERTotal(filepath.Join(devPath, "aer_rootport_total_err_cor"))
+	nonFatal, okNonFatal := readAERTotal(filepath.Join(devPath, "aer_rootport_total_err_nonfatal"))
+	fatal, okFatal := readAERTotal(filepath.Join(devPath, "aer_rootport_total_err_fatal"))
+	if okCor || okNonFatal || okFatal {
+		stats.RootPort = &AERRootPortStats{
+			TotalCorrectable: cor,
+			TotalNonFatal:    nonFatal,
+			TotalFatal:       fatal,
+		}
+	}
+	if stats.Correctable == nil && stats.NonFatal == nil && stats.Fatal == nil && stats.RootPort == nil {
+		return nil
+	}
+	return stats
+}
+
+func readAERCounters(path string) *AERCounters {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return parseAERCounters(data)
+}
+
+func readAERTotal(path string) (uint64, bool) {
+	total, err := strconv.ParseUint(readDeviceString(path), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return total, true
+}
@@ -0,0 +1,126 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci_test
+
+import (
+	"testing"
+)
+
+const aerTestCorrectable = `RxErr 3
+BadTLP 1
+BadDLLP 12
+Rollover 0
+Timeout 2
+NonFatalErr 0
+CorrIntErr 0
+HeaderOF 0
+TOTAL_ERR_COR 18
+`
+
+const aerTestNonFatal = `Undefined 0
+DLP 0
+SDES 0
+TLP 0
+FCP 0
+CmpltTO 1
+CmpltAbrt 0
+UnxCmplt 0
+RxOF 0
+MalfTLP 0
+ECRC 0
+UnsupReq 4
+ACSViol 0
+UncorrIntErr 0
+BlockedTLP 0
+AtomicOpBlocked 0
+TLPBlockedErr 0
+PoisonTLPBlocked 0
+TOTAL_ERR_NONFATAL 5
+`
+
+const aerTestFatal = `Undefined 0
+DLP 0
+SDES 0
+TLP 0
+FCP 0
+CmpltTO 0
+CmpltAbrt 0
+UnxCmplt 0
+RxOF 0
+MalfTLP 0
+ECRC 0
+UnsupReq 0
+ACSViol 0
+UncorrIntErr 0
+BlockedTLP 0
+AtomicOpBlocked 0
+TLPBlockedErr 0
+PoisonTLPBlocked 0
+TOTAL_ERR_FATAL 0
+`
+
+func TestPCIAER(t *testing.T) {
+	info := pciTestSetupFake(t, []pciTestDevice{
+		{
+			addr: "0000:00:1c.0",
+			attrs: map[string]string{
+				"aer_dev_correctable":             aerTestCorrectable,
+				"aer_dev_nonfatal":                aerTestNonFatal,
+				"aer_dev_fatal":                   aerTestFatal,
+				"aer_rootport_total_err_cor":      "20\n",
+				"aer_rootport_total_err_nonfatal": "5\n",
+				"aer_rootport_total_err_fatal":    "0\n",
+			},
+		},
+		{
+			addr:       "0000:01:00.0",
+			parentAddr: "0000:00:1c.0",
+			attrs: map[string]string{
+				"aer_dev_correctable": "RxErr 0\nBadTLP 0\nTOTAL_ERR_COR 0\n",
+				"aer_dev_nonfatal":    "Undefined 0\nTOTAL_ERR_NONFATAL 0\n",
+				"aer_dev_fatal":       "Undefined 0\nTOTAL_ERR_FATAL 0\n",
+			},
+		},
+		{
+			addr: "0000:00:1f.0",
+		},
+	})
+
+	port := info.GetDevice("0000:00:1c.0")
+	if port == nil || port.AER == nil {
+		t.Fatalf("expected AER stats for the root port")
+	}
+	aer := port.AER
+	if !aer.HasErrors() {
+		t.Errorf("expected errors to be reported")
+	}
+	if aer.Correctable.Total != 18 || aer.NonFatal.Total != 5 || aer.Fatal.Total != 0 {
+		t.Errorf("unexpected totals: %v", aer)
+	}
+	if len(aer.Correctable.Errors) != 8 || len(aer.NonFatal.Errors) != 18 || len(aer.Fatal.Errors) != 18 {
+		t.Errorf("expected all the error types to be parsed, got %v %v %v",
+			aer.Correctable.Errors, aer.NonFatal.Errors, aer.Fatal.Errors)
+	}
+	if aer.Correctable.Errors["BadDLLP"] != 12 || aer.NonFatal.Errors["UnsupReq"] != 4 {
+		t.Errorf("unexpected counters: %v %v", aer.Correctable.Errors, aer.NonFatal.Errors)
+	}
+	if aer.RootPort == nil || aer.RootPort.TotalCorrectable != 20 || aer.RootPort.TotalNonFatal != 5 {
+		t.Errorf("unexpected root port totals: %+v", aer.RootPort)
+	}
+
+	ep := info.GetDevice("0000:01:00.0")
+	if ep == nil || ep.AER == nil {
+		t.Fatalf("expected AER stats for the endpoint")
+	}
+	if ep.AER.HasErrors() || ep.AER.RootPort != nil {
+		t.Errorf("unexpected endpoint AER stats: %v %+v", ep.AER, ep.AER.RootPort)
+	}
+
+	if dev := info.GetDevice("0000:00:1f.0"); dev.AER != nil {
+		t.Errorf("expected no AER stats, got %v", dev.AER)
+	}
+}
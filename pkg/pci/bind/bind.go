@@ -0,0 +1,78 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// Package bind changes the kernel driver PCI devices are bound to, most
+// notably to hand devices over to the vfio-pci driver for passthrough to
+// virtual machines, and to give them back to their original driver
+// afterwards.
+package bind
+
+import (
+	"fmt"
+
+	"github.com/zededa/ghw/pkg/option"
+)
+
+const (
+	// VFIODriver is the name of the kernel driver exposing PCI devices to
+	// userspace for passthrough
+	VFIODriver = "vfio-pci"
+)
+
+// Action is a write to a sysfs file performed, or only planned in dry-run
+// mode, by a Binder.
+type Action struct {
+	Path  string `json:"path"`
+	Value string `json:"value"`
+}
+
+func (a Action) String() string {
+	return fmt.Sprintf("echo %q > %s", a.Value, a.Path)
+}
+
+// Binding records the state of a PCI device before it was bound to a new
+// driver, so it can be restored later.
+type Binding struct {
+	// The PCI address of the device
+	Address string `json:"address"`
+	// The driver the device is now bound to
+	Driver string `json:"driver"`
+	// The driver the device was bound to; empty if it was unbound
+	OriginalDriver string `json:"original_driver"`
+	// The content of the driver_override attribute before binding
+	OriginalOverride string `json:"original_override"`
+}
+
+func (b *Binding) String() string {
+	orig := b.OriginalDriver
+	if orig == "" {
+		orig = "(none)"
+	}
+	return fmt.Sprintf("%s: %s -> %s", b.Address, orig, b.Driver)
+}
+
+// Binder binds PCI devices to drivers by writing to the driver_override,
+// unbind, bind and drivers_probe files of the sysfs PCI bus. It honours the
+// chroot and path overrides options, so it can operate on a fake sysfs tree.
+type Binder struct {
+	// When DryRun is true no sysfs file is written; Actions records what
+	// would have been written.
+	DryRun bool
+	// All the sysfs writes performed or, in dry-run mode, planned so far
+	Actions []Action
+
+	opts *option.Options
+}
+
+// New returns a Binder operating on the sysfs tree selected by the supplied
+// options.
+func New(opt ...option.Option) *Binder {
+	opts := option.FromEnv()
+	for _, o := range opt {
+		o(opts)
+	}
+	return &Binder{opts: opts}
+}
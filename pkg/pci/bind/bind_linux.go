@@ -0,0 +1,209 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package bind
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zededa/ghw/pkg/linuxpath"
+	"github.com/zededa/ghw/pkg/pci"
+	pciaddr "github.com/zededa/ghw/pkg/pci/address"
+)
+
+// Bind binds the PCI device at the given address to the given driver,
+// unbinding it from its current driver first. The sequence is:
+//
+//  1. write the driver name into the device driver_override attribute, so
+//     that no other driver can claim the device
+//  2. unbind the device from its current driver, if any
+//  3. ask the kernel to probe the device again via drivers_probe
+//  4. check the device is now bound to the driver, restoring it otherwise
+//
+// The returned Binding can be passed to Restore to undo the operation.
+func (b *Binder) Bind(address, driver string) (*Binding, error) {
+	paths := linuxpath.New(b.opts)
+	devDir, err := deviceDir(paths, address)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(paths.SysBusPciDrivers, driver)); err != nil {
+		return nil, fmt.Errorf("driver %q is not loaded: %w", driver, err)
+	}
+
+	binding := &Binding{
+		Address:          address,
+		Driver:           driver,
+		OriginalDriver:   currentDriver(devDir),
+		OriginalOverride: readOverride(devDir),
+	}
+	if binding.OriginalDriver == driver {
+		return binding, nil
+	}
+
+	if err := b.write(filepath.Join(devDir, "driver_override"), driver); err != nil {
+		return nil, err
+	}
+	if binding.OriginalDriver != "" {
+		if err := b.write(filepath.Join(devDir, "driver", "unbind"), address); err != nil {
+			if rerr := b.restoreOverride(devDir, binding); rerr != nil {
+				return nil, fmt.Errorf("%w (restore failed: %v)", err, rerr)
+			}
+			return nil, err
+		}
+	}
+	if err := probe(b, paths.SysBusPciDriversProbe, address); err != nil {
+		// leave the device as we found it
+		_ = b.Restore(binding)
+		return nil, err
+	}
+	if b.DryRun {
+		return binding, nil
+	}
+	// the probe fails silently, e.g. when the driver does not support the
+	// device or its probe routine fails
+	if bound := currentDriver(devDir); bound != driver {
+		if bound == "" {
+			bound = "(none)"
+		}
+		err := fmt.Errorf("device %q is bound to %s instead of %s after probing", address, bound, driver)
+		if rerr := b.Restore(binding); rerr != nil {
+			return nil, fmt.Errorf("%w (restore failed: %v)", err, rerr)
+		}
+		return nil, err
+	}
+	return binding, nil
+}
+
+// probe asks the kernel to probe the device at the given address again by
+// writing it to the given drivers_probe file. Tests replace it to play the
+// part of the kernel.
+var probe = func(b *Binder, driversProbe, address string) error {
+	return b.write(driversProbe, address)
+}
+
+// BindVFIO binds the PCI device at the given address to vfio-pci.
+func (b *Binder) BindVFIO(address string) (*Binding, error) {
+	return b.Bind(address, VFIODriver)
+}
+
+// BindGroupVFIO binds all the devices of an IOMMU group to vfio-pci, which
+// is required before the group can be handed to a guest. Bridges are left to
+// their driver, since vfio allows them in a group. If any device fails to
+// bind, the devices already bound are restored.
+func (b *Binder) BindGroupVFIO(group *pci.IOMMUGroup) ([]*Binding, error) {
+	bindings := []*Binding{}
+	for _, dev := range group.Devices {
+		if dev.IsBridge() {
+			continue
+		}
+		binding, err := b.BindVFIO(dev.Address)
+		if err != nil {
+			if rerr := b.RestoreAll(bindings); rerr != nil {
+				return nil, fmt.Errorf("%w (restore failed: %v)", err, rerr)
+			}
+			return nil, err
+		}
+		bindings = append(bindings, binding)
+	}
+	return bindings, nil
+}
+
+// Restore binds a device back to the driver recorded in the binding, and
+// restores its original driver_override.
+func (b *Binder) Restore(binding *Binding) error {
+	paths := linuxpath.New(b.opts)
+	devDir, err := deviceDir(paths, binding.Address)
+	if err != nil {
+		return err
+	}
+	if err := b.restoreOverride(devDir, binding); err != nil {
+		return err
+	}
+	if binding.OriginalDriver == binding.Driver {
+		return nil
+	}
+	if b.DryRun || currentDriver(devDir) != "" {
+		if err := b.write(filepath.Join(devDir, "driver", "unbind"), binding.Address); err != nil {
+			return err
+		}
+	}
+	if binding.OriginalDriver == "" {
+		return nil
+	}
+	return b.write(filepath.Join(paths.SysBusPciDrivers, binding.OriginalDriver, "bind"), binding.Address)
+}
+
+// RestoreAll restores all the given bindings, in reverse order. It carries on
+// after failures and returns all the errors met.
+func (b *Binder) RestoreAll(bindings []*Binding) error {
+	var errs []error
+	for idx := len(bindings) - 1; idx >= 0; idx-- {
+		if err := b.Restore(bindings[idx]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (b *Binder) restoreOverride(devDir string, binding *Binding) error {
+	// writing a newline clears the override
+	return b.write(filepath.Join(devDir, "driver_override"), binding.OriginalOverride+"\n")
+}
+
+// write records and, unless in dry-run mode, performs a write of value to a
+// sysfs file. The file must exist: sysfs does not allow creating files.
+func (b *Binder) write(path, value string) error {
+	b.Actions = append(b.Actions, Action{Path: path, Value: value})
+	if b.DryRun {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %q: %w", path, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(value); err != nil {
+		return fmt.Errorf("failed to write %q to %q: %w", value, path, err)
+	}
+	return nil
+}
+
+func deviceDir(paths *linuxpath.Paths, address string) (string, error) {
+	addr := pciaddr.FromString(address)
+	if addr == nil {
+		return "", fmt.Errorf("invalid PCI address %q", address)
+	}
+	devDir := filepath.Join(paths.SysBusPciDevices, addr.String())
+	if _, err := os.Stat(devDir); err != nil {
+		return "", fmt.Errorf("PCI device %q not found: %w", address, err)
+	}
+	return devDir, nil
+}
+
+func currentDriver(devDir string) string {
+	dest, err := os.Readlink(filepath.Join(devDir, "driver"))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(dest)
+}
+
+func readOverride(devDir string) string {
+	data, err := os.ReadFile(filepath.Join(devDir, "driver_override"))
+	if err != nil {
+		return ""
+	}
+	override := strings.TrimSpace(string(data))
+	// the kernel reports an unset override as "(null)"
+	if override == "(null)" {
+		return ""
+	}
+	return override
+}
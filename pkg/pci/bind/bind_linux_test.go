@@ -0,0 +1,181 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package bind_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zededa/ghw/pkg/option"
+	"github.com/zededa/ghw/pkg/pci"
+	"github.com/zededa/ghw/pkg/pci/bind"
+)
+
+const (
+	bindTestNIC  = "0000:03:00.0"
+	bindTestFunc = "0000:03:00.1"
+)
+
+// bindTestSetupFakeSysfs creates a sysfs tree with two functions bound to the
+// ixgbe driver, and the vfio-pci driver loaded. Writes to the sysfs files are
+// simply stored in them.
+func bindTestSetupFakeSysfs(t *testing.T) string {
+	root := t.TempDir()
+	busPath := filepath.Join(root, "sys", "bus", "pci")
+	for _, drv := range []string{"ixgbe", bind.VFIODriver} {
+		bindTestWriteFile(t, filepath.Join(busPath, "drivers", drv, "bind"), "")
+		bindTestWriteFile(t, filepath.Join(busPath, "drivers", drv, "unbind"), "")
+	}
+	bindTestWriteFile(t, filepath.Join(busPath, "drivers_probe"), "")
+	for _, addr := range []string{bindTestNIC, bindTestFunc} {
+		devPath := filepath.Join(busPath, "devices", addr)
+		bindTestWriteFile(t, filepath.Join(devPath, "driver_override"), "(null)\n")
+		if err := os.Symlink("../../drivers/ixgbe", filepath.Join(devPath, "driver")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// bindTestSetDriver points the driver link of the device at the given
+// address to the given driver, as the kernel does when binding it
+func bindTestSetDriver(t *testing.T, root, address, driver string) {
+	link := filepath.Join(root, "sys", "bus", "pci", "devices", address, "driver")
+	_ = os.Remove(link)
+	if err := os.Symlink(filepath.Join("..", "..", "drivers", driver), link); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func bindTestWriteFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func bindTestReadFile(t *testing.T, root, path string) string {
+	data, err := os.ReadFile(filepath.Join(root, path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestBindVFIO(t *testing.T) {
+	root := bindTestSetupFakeSysfs(t)
+	binder := bind.New(option.WithChroot(root))
+	bind.SetAfterProbe(t, func(address string) {
+		bindTestSetDriver(t, root, address, bind.VFIODriver)
+	})
+
+	binding, err := binder.BindVFIO(bindTestNIC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if binding.OriginalDriver != "ixgbe" || binding.OriginalOverride != "" {
+		t.Fatalf("unexpected binding: %+v", binding)
+	}
+	devPath := filepath.Join("sys", "bus", "pci", "devices", bindTestNIC)
+	if got := bindTestReadFile(t, root, filepath.Join(devPath, "driver_override")); got != bind.VFIODriver {
+		t.Fatalf("expected driver_override %q, got %q", bind.VFIODriver, got)
+	}
+	if got := bindTestReadFile(t, root, "sys/bus/pci/drivers/ixgbe/unbind"); got != bindTestNIC {
+		t.Fatalf("expected unbind of %q, got %q", bindTestNIC, got)
+	}
+	if got := bindTestReadFile(t, root, "sys/bus/pci/drivers_probe"); got != bindTestNIC {
+		t.Fatalf("expected probe of %q, got %q", bindTestNIC, got)
+	}
+	if len(binder.Actions) != 3 {
+		t.Fatalf("expected 3 actions, got %v", binder.Actions)
+	}
+
+	if err := binder.Restore(binding); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := bindTestReadFile(t, root, filepath.Join(devPath, "driver_override")); got != "\n" {
+		t.Fatalf("expected driver_override to be cleared, got %q", got)
+	}
+	if got := bindTestReadFile(t, root, "sys/bus/pci/drivers/vfio-pci/unbind"); got != bindTestNIC {
+		t.Fatalf("expected vfio-pci unbind of %q, got %q", bindTestNIC, got)
+	}
+	if got := bindTestReadFile(t, root, "sys/bus/pci/drivers/ixgbe/bind"); got != bindTestNIC {
+		t.Fatalf("expected ixgbe bind of %q, got %q", bindTestNIC, got)
+	}
+}
+
+func TestBindDryRun(t *testing.T) {
+	root := bindTestSetupFakeSysfs(t)
+	binder := bind.New(option.WithChroot(root))
+	binder.DryRun = true
+
+	group := &pci.IOMMUGroup{
+		ID: "12",
+		Devices: []*pci.Device{
+			{Address: bindTestNIC},
+			{Address: bindTestFunc},
+		},
+	}
+	bindings, err := binder.BindGroupVFIO(group)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(bindings) != 2 {
+		t.Fatalf("expected 2 bindings, got %d", len(bindings))
+	}
+	if len(binder.Actions) != 6 {
+		t.Fatalf("expected 6 actions, got %v", binder.Actions)
+	}
+	if got := bindTestReadFile(t, root, "sys/bus/pci/drivers_probe"); got != "" {
+		t.Fatalf("expected no write in dry-run mode, got %q", got)
+	}
+	if !strings.HasSuffix(binder.Actions[0].Path, filepath.Join(bindTestNIC, "driver_override")) {
+		t.Fatalf("unexpected first action %v", binder.Actions[0])
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	root := bindTestSetupFakeSysfs(t)
+	binder := bind.New(option.WithChroot(root))
+
+	if _, err := binder.Bind(bindTestNIC, "nosuchdriver"); err == nil {
+		t.Fatalf("expected error binding to a driver not loaded")
+	}
+	if _, err := binder.BindVFIO("0000:7f:00.0"); err == nil {
+		t.Fatalf("expected error binding a missing device")
+	}
+	if _, err := binder.BindVFIO("notanaddress"); err == nil {
+		t.Fatalf("expected error binding an invalid address")
+	}
+	if len(binder.Actions) != 0 {
+		t.Fatalf("expected no actions, got %v", binder.Actions)
+	}
+}
+
+func TestBindProbeFailure(t *testing.T) {
+	root := bindTestSetupFakeSysfs(t)
+	binder := bind.New(option.WithChroot(root))
+	// the kernel unbinds the device, but vfio-pci does not claim it
+	bind.SetAfterProbe(t, func(address string) {
+		_ = os.Remove(filepath.Join(root, "sys", "bus", "pci", "devices", address, "driver"))
+	})
+
+	if _, err := binder.BindVFIO(bindTestNIC); err == nil {
+		t.Fatalf("expected error when the device is not bound after probing")
+	}
+	devPath := filepath.Join("sys", "bus", "pci", "devices", bindTestNIC)
+	if got := bindTestReadFile(t, root, filepath.Join(devPath, "driver_override")); got != "\n" {
+		t.Fatalf("expected driver_override to be cleared, got %q", got)
+	}
+	if got := bindTestReadFile(t, root, "sys/bus/pci/drivers/ixgbe/bind"); got != bindTestNIC {
+		t.Fatalf("expected ixgbe bind of %q, got %q", bindTestNIC, got)
+	}
+}
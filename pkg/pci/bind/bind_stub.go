@@ -0,0 +1,43 @@
+//go:build !linux
+// +build !linux
+
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package bind
+
+import (
+	"errors"
+	"runtime"
+
+	"github.com/zededa/ghw/pkg/pci"
+)
+
+var errNotImplemented = errors.New("PCI driver binding not implemented on " + runtime.GOOS)
+
+// Bind binds the PCI device at the given address to the given driver.
+func (b *Binder) Bind(address, driver string) (*Binding, error) {
+	return nil, errNotImplemented
+}
+
+// BindVFIO binds the PCI device at the given address to vfio-pci.
+func (b *Binder) BindVFIO(address string) (*Binding, error) {
+	return nil, errNotImplemented
+}
+
+// BindGroupVFIO binds all the devices of an IOMMU group to vfio-pci.
+func (b *Binder) BindGroupVFIO(group *pci.IOMMUGroup) ([]*Binding, error) {
+	return nil, errNotImplemented
+}
+
+// Restore binds a device back to the driver recorded in the binding.
+func (b *Binder) Restore(binding *Binding) error {
+	return errNotImplemented
+}
+
+// RestoreAll restores all the given bindings.
+func (b *Binder) RestoreAll(bindings []*Binding) error {
+	return errNotImplemented
+}
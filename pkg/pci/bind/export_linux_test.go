@@ -0,0 +1,23 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package bind
+
+import "testing"
+
+// SetAfterProbe makes fn run after each write to drivers_probe, in place of
+// the kernel binding the device, until the end of the test.
+func SetAfterProbe(t *testing.T, fn func(address string)) {
+	orig := probe
+	probe = func(b *Binder, driversProbe, address string) error {
+		if err := orig(b, driversProbe, address); err != nil {
+			return err
+		}
+		fn(address)
+		return nil
+	}
+	t.Cleanup(func() { probe = orig })
+}
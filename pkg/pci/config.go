@@ -0,0 +1,543 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// The layout of the PCI configuration space and of the capability structures
+// is described by the PCI Local Bus and the PCI Express Base specifications.
+const (
+	configHeaderSize    = 0x40
+	configStatus        = 0x06
+	configHeaderType    = 0x0e
+	configCapPtr        = 0x34
+	configCardbusCapPtr = 0x14
+	configExtCapStart   = 0x100
+
+	configStatusCapList = 0x10
+
+	headerTypeMask    = 0x7f
+	headerTypeCardbus = 0x02
+)
+
+// CapabilityID identifies a standard or extended PCI capability.
+type CapabilityID uint16
+
+// Standard capability IDs
+const (
+	CapabilityIDPowerManagement CapabilityID = 0x01
+	CapabilityIDMSI             CapabilityID = 0x05
+	CapabilityIDExpress         CapabilityID = 0x10
+	CapabilityIDMSIX            CapabilityID = 0x11
+)
+
+// Extended capability IDs
+const (
+	ExtCapabilityIDAER   CapabilityID = 0x0001
+	ExtCapabilityIDACS   CapabilityID = 0x000d
+	ExtCapabilityIDATS   CapabilityID = 0x000f
+	ExtCapabilityIDSRIOV CapabilityID = 0x0010
+	ExtCapabilityIDPRI   CapabilityID = 0x0013
+	ExtCapabilityIDPASID CapabilityID = 0x001b
+)
+
+var (
+	capabilityNames = map[CapabilityID]string{
+		CapabilityIDPowerManagement: "Power Management",
+		0x02:                        "AGP",
+		0x03:                        "VPD",
+		0x04:                        "Slot Identification",
+		CapabilityIDMSI:             "MSI",
+		0x09:                        "Vendor Specific",
+		0x0a:                        "Debug Port",
+		0x0d:                        "Bridge Subsystem Vendor ID",
+		CapabilityIDExpress:         "PCI Express",
+		CapabilityIDMSIX:            "MSI-X",
+		0x12:                        "SATA",
+		0x13:                        "Advanced Features",
+		0x14:                        "Enhanced Allocation",
+	}
+	extCapabilityNames = map[CapabilityID]string{
+		ExtCapabilityIDAER:   "Advanced Error Reporting",
+		0x0002:               "Virtual Channel",
+		0x0003:               "Device Serial Number",
+		0x0004:               "Power Budgeting",
+		0x000b:               "Vendor Specific",
+		ExtCapabilityIDACS:   "Access Control Services",
+		0x000e:               "Alternative Routing-ID Interpretation",
+		ExtCapabilityIDATS:   "Address Translation Services",
+		ExtCapabilityIDSRIOV: "Single Root I/O Virtualization",
+		0x0015:               "Resizable BAR",
+		ExtCapabilityIDPRI:   "Page Request Interface",
+		0x0017:               "TPH Requester",
+		0x0018:               "Latency Tolerance Reporting",
+		0x0019:               "Secondary PCI Express",
+		ExtCapabilityIDPASID: "Process Address Space ID",
+		0x001e:               "L1 PM Substates",
+		0x0025:               "Data Link Feature",
+		0x0026:               "Physical Layer 16.0 GT/s",
+		0x0027:               "Lane Margining at the Receiver",
+	}
+)
+
+// Capability is an entry of the standard or extended capability list of a
+// PCI device.
+type Capability struct {
+	ID CapabilityID `json:"id"`
+	// Offset of the capability structure in the configuration space
+	Offset int `json:"offset"`
+	// True for PCI Express extended capabilities
+	Extended bool `json:"extended"`
+	// Capability structure version; always 0 for standard capabilities
+	Version uint8 `json:"version"`
+}
+
+// Name returns the human-readable name of the capability.
+func (c Capability) Name() string {
+	names := capabilityNames
+	if c.Extended {
+		names = extCapabilityNames
+	}
+	if name, ok := names[c.ID]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (%#x)", uint16(c.ID))
+}
+
+func (c Capability) String() string {
+	return fmt.Sprintf("[%#x] %s", c.Offset, c.Name())
+}
+
+// PMCapability describes the PCI Power Management capability.
+type PMCapability struct {
+	Version uint8 `json:"version"`
+	// True if the D1 and D2 low power states are supported
+	D1Support bool `json:"d1_support"`
+	D2Support bool `json:"d2_support"`
+	// Bitmask of the states (bit 0 = D0 ... bit 4 = D3cold) PME can be
+	// signalled from
+	PMESupport uint8 `json:"pme_support"`
+	// Current power state: 0 = D0 ... 3 = D3hot
+	PowerState uint8 `json:"power_state"`
+	// True if the device keeps its state when going from D3hot to D0, in
+	// which case the transition cannot be used to reset it
+	NoSoftReset bool `json:"no_soft_reset"`
+}
+
+// MSICapability describes the Message Signaled Interrupts capability.
+type MSICapability struct {
+	Enabled bool `json:"enabled"`
+	// Number of vectors the device can request
+	MaxVectors int `json:"max_vectors"`
+	// Number of vectors allocated by the system
+	Vectors       int  `json:"vectors"`
+	Address64     bool `json:"address_64"`
+	PerVectorMask bool `json:"per_vector_mask"`
+}
+
+// MSIXCapability describes the MSI-X capability.
+type MSIXCapability struct {
+	Enabled      bool `json:"enabled"`
+	FunctionMask bool `json:"function_mask"`
+	// Number of entries of the MSI-X table
+	TableSize int `json:"table_size"`
+	// BAR index and offset locating the MSI-X table
+	TableBIR    uint8  `json:"table_bir"`
+	TableOffset uint32 `json:"table_offset"`
+	// BAR index and offset locating the Pending Bit Array
+	PBABIR    uint8  `json:"pba_bir"`
+	PBAOffset uint32 `json:"pba_offset"`
+}
+
+// ExpressPortType is the device/port type field of the PCI Express
+// capability.
+type ExpressPortType uint8
+
+const (
+	ExpressPortTypeEndpoint         ExpressPortType = 0x0
+	ExpressPortTypeLegacyEndpoint   ExpressPortType = 0x1
+	ExpressPortTypeRootPort         ExpressPortType = 0x4
+	ExpressPortTypeUpstreamPort     ExpressPortType = 0x5
+	ExpressPortTypeDownstreamPort   ExpressPortType = 0x6
+	ExpressPortTypePCIeToPCIBridge  ExpressPortType = 0x7
+	ExpressPortTypePCIToPCIeBridge  ExpressPortType = 0x8
+	ExpressPortTypeRCIntegratedEP   ExpressPortType = 0x9
+	ExpressPortTypeRCEventCollector ExpressPortType = 0xa
+)
+
+var expressPortTypeStrings = map[ExpressPortType]string{
+	ExpressPortTypeEndpoint:         "endpoint",
+	ExpressPortTypeLegacyEndpoint:   "legacy-endpoint",
+	ExpressPortTypeRootPort:         "root-port",
+	ExpressPortTypeUpstreamPort:     "upstream-port",
+	ExpressPortTypeDownstreamPort:   "downstream-port",
+	ExpressPortTypePCIeToPCIBridge:  "pcie-to-pci-bridge",
+	ExpressPortTypePCIToPCIeBridge:  "pci-to-pcie-bridge",
+	ExpressPortTypeRCIntegratedEP:   "rc-integrated-endpoint",
+	ExpressPortTypeRCEventCollector: "rc-event-collector",
+}
+
+func (t ExpressPortType) String() string {
+	if s, ok := expressPortTypeStrings[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("unknown (%#x)", uint8(t))
+}
+
+func (t ExpressPortType) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(t.String())), nil
+}
+
+func (t *ExpressPortType) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	for val, str := range expressPortTypeStrings {
+		if str == s {
+			*t = val
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown PCI Express port type: %q", s)
+}
+
+// ExpressCapability describes the PCI Express capability.
+type ExpressCapability struct {
+	Version  uint8           `json:"version"`
+	PortType ExpressPortType `json:"port_type"`
+	// Maximum TLP payload size supported, in bytes
+	MaxPayloadSize int `json:"max_payload_size"`
+	// True if the device supports Function Level Reset
+	FLR bool `json:"flr"`
+	// Maximum link speed, as encoded in the Link Capabilities register:
+	// 1 = 2.5 GT/s, 2 = 5 GT/s, 3 = 8 GT/s, ...
+	LinkMaxSpeed uint8 `json:"link_max_speed"`
+	LinkMaxWidth uint8 `json:"link_max_width"`
+	// Negotiated link speed and width, from the Link Status register
+	LinkSpeed uint8 `json:"link_speed"`
+	LinkWidth uint8 `json:"link_width"`
+	// True if the port is connected to a slot
+	SlotImplemented bool `json:"slot_implemented"`
+	// Physical slot number and hotplug capability; only meaningful if
+	// SlotImplemented is true
+	SlotNumber     uint16 `json:"slot_number,omitempty"`
+	HotplugCapable bool   `json:"hotplug_capable,omitempty"`
+}
+
+// ACS control and capability bits
+const (
+	ACSSourceValidation    = 0x0001
+	ACSTranslationBlocking = 0x0002
+	ACSRequestRedirect     = 0x0004
+	ACSCompletionRedirect  = 0x0008
+	ACSUpstreamForwarding  = 0x0010
+	ACSEgressControl       = 0x0020
+	ACSDirectTranslatedP2P = 0x0040
+)
+
+// ACSCapability describes the Access Control Services extended capability.
+type ACSCapability struct {
+	// Bitmask of the supported ACS features (ACS* constants)
+	Capability uint16 `json:"capability"`
+	// Bitmask of the enabled ACS features (ACS* constants)
+	Control uint16 `json:"control"`
+}
+
+// AERCapability describes the Advanced Error Reporting extended capability.
+type AERCapability struct {
+	UncorrectableStatus   uint32 `json:"uncorrectable_status"`
+	UncorrectableMask     uint32 `json:"uncorrectable_mask"`
+	UncorrectableSeverity uint32 `json:"uncorrectable_severity"`
+	CorrectableStatus     uint32 `json:"correctable_status"`
+	CorrectableMask       uint32 `json:"correctable_mask"`
+}
+
+// SRIOVCapability describes the Single Root I/O Virtualization extended
+// capability.
+type SRIOVCapability struct {
+	VFEnabled  bool   `json:"vf_enabled"`
+	InitialVFs uint16 `json:"initial_vfs"`
+	TotalVFs   uint16 `json:"total_vfs"`
+	NumVFs     uint16 `json:"num_vfs"`
+	VFOffset   uint16 `json:"vf_offset"`
+	VFStride   uint16 `json:"vf_stride"`
+	VFDeviceID uint16 `json:"vf_device_id"`
+}
+
+// ATSCapability describes the Address Translation Services extended
+// capability.
+type ATSCapability struct {
+	Enabled bool `json:"enabled"`
+	// Number of invalidate requests the device can queue; 0 means 32
+	InvalidateQueueDepth uint8 `json:"invalidate_queue_depth"`
+	PageAlignedRequest   bool  `json:"page_aligned_request"`
+	// Smallest translation unit, as a power of two multiple of 4096 bytes
+	SmallestTranslationUnit uint8 `json:"smallest_translation_unit"`
+}
+
+// PRICapability describes the Page Request Interface extended capability.
+type PRICapability struct {
+	Enabled bool `json:"enabled"`
+	// Maximum number of outstanding page requests the device can issue
+	OutstandingCapacity uint32 `json:"outstanding_capacity"`
+	// Number of outstanding page requests the system allows
+	OutstandingAllocation uint32 `json:"outstanding_allocation"`
+}
+
+// PASIDCapability describes the Process Address Space ID extended capability.
+type PASIDCapability struct {
+	Enabled           bool  `json:"enabled"`
+	ExecutePermission bool  `json:"execute_permission"`
+	PrivilegedMode    bool  `json:"privileged_mode"`
+	MaxWidth          uint8 `json:"max_width"`
+}
+
+// Capabilities holds the capabilities decoded from the configuration space
+// of a PCI device. Unprivileged users can only read the first 64 bytes of
+// the configuration space, in which case List is empty; extended
+// capabilities are only available if more than 256 bytes could be read.
+type Capabilities struct {
+	// Number of bytes of configuration space that could be read
+	ConfigSize int `json:"config_size"`
+	// Header type: 0 = endpoint, 1 = PCI-to-PCI bridge, 2 = CardBus bridge
+	HeaderType uint8 `json:"header_type"`
+	// All the standard and extended capabilities found, in list order
+	List            []Capability       `json:"list"`
+	PowerManagement *PMCapability      `json:"power_management,omitempty"`
+	MSI             *MSICapability     `json:"msi,omitempty"`
+	MSIX            *MSIXCapability    `json:"msix,omitempty"`
+	Express         *ExpressCapability `json:"express,omitempty"`
+	ACS             *ACSCapability     `json:"acs,omitempty"`
+	AER             *AERCapability     `json:"aer,omitempty"`
+	SRIOV           *SRIOVCapability   `json:"sriov,omitempty"`
+	ATS             *ATSCapability     `json:"ats,omitempty"`
+	PRI             *PRICapability     `json:"pri,omitempty"`
+	PASID           *PASIDCapability   `json:"pasid,omitempty"`
+}
+
+// Has returns true if the capability list contains the given standard or
+// extended capability.
+func (c *Capabilities) Has(id CapabilityID, extended bool) bool {
+	for _, capa := range c.List {
+		if capa.ID == id && capa.Extended == extended {
+			return true
+		}
+	}
+	return false
+}
+
+// configSpace gives bounds-checked little-endian access to the raw content
+// of a configuration space. Reads past the available data return 0.
+type configSpace []byte
+
+func (cs configSpace) u8(off int) uint8 {
+	if off < 0 || off >= len(cs) {
+		return 0
+	}
+	return cs[off]
+}
+
+func (cs configSpace) u16(off int) uint16 {
+	if off < 0 || off+2 > len(cs) {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(cs[off:])
+}
+
+func (cs configSpace) u32(off int) uint32 {
+	if off < 0 || off+4 > len(cs) {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(cs[off:])
+}
+
+func (cs configSpace) has(off, size int) bool {
+	return off >= 0 && off+size <= len(cs)
+}
+
+// ParseConfigSpace decodes the standard and extended capability lists out of
+// the raw content of a PCI configuration space, as read from the sysfs config
+// file of a device. Returns nil if data is shorter than the 64 bytes
+// standard header.
+func ParseConfigSpace(data []byte) *Capabilities {
+	cs := configSpace(data)
+	if len(cs) < configHeaderSize {
+		return nil
+	}
+	caps := &Capabilities{
+		ConfigSize: len(cs),
+		HeaderType: cs.u8(configHeaderType) & headerTypeMask,
+		List:       []Capability{},
+	}
+	caps.parseStandard(cs)
+	caps.parseExtended(cs)
+	return caps
+}
+
+func (c *Capabilities) parseStandard(cs configSpace) {
+	if cs.u16(configStatus)&configStatusCapList == 0 {
+		return
+	}
+	ptrOff := configCapPtr
+	if c.HeaderType == headerTypeCardbus {
+		ptrOff = configCardbusCapPtr
+	}
+	off := int(cs.u8(ptrOff) &^ 0x3)
+	// each capability takes at least 4 bytes, so there can be at most 48 of
+	// them; the limit protects us against loops in corrupted lists.
+	for ttl := 48; off >= configHeaderSize && ttl > 0; ttl-- {
+		if !cs.has(off, 2) {
+			return
+		}
+		id := CapabilityID(cs.u8(off))
+		if id == 0xff {
+			return
+		}
+		c.List = append(c.List, Capability{ID: id, Offset: off})
+		c.decodeStandard(cs, id, off)
+		off = int(cs.u8(off+1) &^ 0x3)
+	}
+}
+
+func (c *Capabilities) parseExtended(cs configSpace) {
+	off := configExtCapStart
+	for ttl := (4096 - configExtCapStart) / 8; off >= configExtCapStart && ttl > 0; ttl-- {
+		if !cs.has(off, 4) {
+			return
+		}
+		header := cs.u32(off)
+		if header == 0 || header == 0xffffffff {
+			return
+		}
+		id := CapabilityID(header & 0xffff)
+		c.List = append(c.List, Capability{
+			ID:       id,
+			Offset:   off,
+			Extended: true,
+			Version:  uint8((header >> 16) & 0xf),
+		})
+		c.decodeExtended(cs, id, off)
+		off = int((header >> 20) &^ 0x3)
+	}
+}
+
+func (c *Capabilities) decodeStandard(cs configSpace, id CapabilityID, off int) {
+	switch id {
+	case CapabilityIDPowerManagement:
+		pmc := cs.u16(off + 2)
+		pmcsr := cs.u16(off + 4)
+		c.PowerManagement = &PMCapability{
+			Version:     uint8(pmc & 0x7),
+			D1Support:   pmc&(1<<9) != 0,
+			D2Support:   pmc&(1<<10) != 0,
+			PMESupport:  uint8(pmc >> 11),
+			PowerState:  uint8(pmcsr & 0x3),
+			NoSoftReset: pmcsr&(1<<3) != 0,
+		}
+	case CapabilityIDMSI:
+		ctrl := cs.u16(off + 2)
+		c.MSI = &MSICapability{
+			Enabled:       ctrl&0x1 != 0,
+			MaxVectors:    1 << ((ctrl >> 1) & 0x7),
+			Vectors:       1 << ((ctrl >> 4) & 0x7),
+			Address64:     ctrl&(1<<7) != 0,
+			PerVectorMask: ctrl&(1<<8) != 0,
+		}
+	case CapabilityIDMSIX:
+		ctrl := cs.u16(off + 2)
+		table := cs.u32(off + 4)
+		pba := cs.u32(off + 8)
+		c.MSIX = &MSIXCapability{
+			Enabled:      ctrl&(1<<15) != 0,
+			FunctionMask: ctrl&(1<<14) != 0,
+			TableSize:    int(ctrl&0x7ff) + 1,
+			TableBIR:     uint8(table & 0x7),
+			TableOffset:  table &^ 0x7,
+			PBABIR:       uint8(pba & 0x7),
+			PBAOffset:    pba &^ 0x7,
+		}
+	case CapabilityIDExpress:
+		flags := cs.u16(off + 2)
+		devCap := cs.u32(off + 4)
+		linkCap := cs.u32(off + 0x0c)
+		linkStatus := cs.u16(off + 0x12)
+		express := &ExpressCapability{
+			Version:         uint8(flags & 0xf),
+			PortType:        ExpressPortType((flags >> 4) & 0xf),
+			MaxPayloadSize:  128 << (devCap & 0x7),
+			FLR:             devCap&(1<<28) != 0,
+			LinkMaxSpeed:    uint8(linkCap & 0xf),
+			LinkMaxWidth:    uint8((linkCap >> 4) & 0x3f),
+			LinkSpeed:       uint8(linkStatus & 0xf),
+			LinkWidth:       uint8((linkStatus >> 4) & 0x3f),
+			SlotImplemented: flags&(1<<8) != 0,
+		}
+		if express.SlotImplemented {
+			slotCap := cs.u32(off + 0x14)
+			express.SlotNumber = uint16(slotCap >> 19)
+			express.HotplugCapable = slotCap&(1<<6) != 0
+		}
+		c.Express = express
+	}
+}
+
+func (c *Capabilities) decodeExtended(cs configSpace, id CapabilityID, off int) {
+	switch id {
+	case ExtCapabilityIDAER:
+		c.AER = &AERCapability{
+			UncorrectableStatus:   cs.u32(off + 0x04),
+			UncorrectableMask:     cs.u32(off + 0x08),
+			UncorrectableSeverity: cs.u32(off + 0x0c),
+			CorrectableStatus:     cs.u32(off + 0x10),
+			CorrectableMask:       cs.u32(off + 0x14),
+		}
+	case ExtCapabilityIDACS:
+		c.ACS = &ACSCapability{
+			Capability: cs.u16(off + 4),
+			Control:    cs.u16(off + 6),
+		}
+	case ExtCapabilityIDATS:
+		capa := cs.u16(off + 4)
+		ctrl := cs.u16(off + 6)
+		c.ATS = &ATSCapability{
+			Enabled:                 ctrl&(1<<15) != 0,
+			InvalidateQueueDepth:    uint8(capa & 0x1f),
+			PageAlignedRequest:      capa&(1<<5) != 0,
+			SmallestTranslationUnit: uint8(ctrl & 0x1f),
+		}
+	case ExtCapabilityIDSRIOV:
+		c.SRIOV = &SRIOVCapability{
+			VFEnabled:  cs.u16(off+0x08)&0x1 != 0,
+			InitialVFs: cs.u16(off + 0x0c),
+			TotalVFs:   cs.u16(off + 0x0e),
+			NumVFs:     cs.u16(off + 0x10),
+			VFOffset:   cs.u16(off + 0x14),
+			VFStride:   cs.u16(off + 0x16),
+			VFDeviceID: cs.u16(off + 0x1a),
+		}
+	case ExtCapabilityIDPRI:
+		c.PRI = &PRICapability{
+			Enabled:               cs.u16(off+0x04)&0x1 != 0,
+			OutstandingCapacity:   cs.u32(off + 0x08),
+			OutstandingAllocation: cs.u32(off + 0x0c),
+		}
+	case ExtCapabilityIDPASID:
+		capa := cs.u16(off + 4)
+		c.PASID = &PASIDCapability{
+			Enabled:           cs.u16(off+6)&0x1 != 0,
+			ExecutePermission: capa&(1<<1) != 0,
+			PrivilegedMode:    capa&(1<<2) != 0,
+			MaxWidth:          uint8((capa >> 8) & 0x1f),
+		}
+	}
+}
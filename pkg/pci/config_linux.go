@@ -0,0 +1,25 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/zededa/ghw/pkg/linuxpath"
+	pciaddr "github.com/zededa/ghw/pkg/pci/address"
+)
+
+// getDeviceCapabilities decodes the capabilities out of the config file of
+// the device at the given address. How much of the configuration space the
+// kernel lets us read depends on our privileges.
+func getDeviceCapabilities(paths *linuxpath.Paths, pciAddr *pciaddr.Address) *Capabilities {
+	data, err := os.ReadFile(filepath.Join(paths.SysBusPciDevices, pciAddr.String(), "config"))
+	if err != nil {
+		return nil
+	}
+	return ParseConfigSpace(data)
+}
@@ -0,0 +1,196 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci_test
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/zededa/ghw/pkg/pci"
+)
+
+type configBlob []byte
+
+func (b configBlob) put16(off int, val uint16) {
+	binary.LittleEndian.PutUint16(b[off:], val)
+}
+
+func (b configBlob) put32(off int, val uint32) {
+	binary.LittleEndian.PutUint32(b[off:], val)
+}
+
+func (b configBlob) putExtHeader(off int, id uint16, version uint8, next int) {
+	b.put32(off, uint32(id)|uint32(version)<<16|uint32(next)<<20)
+}
+
+// pciTestConfigBlob returns the 4096 bytes configuration space of a made-up
+// SR-IOV capable PCIe endpoint.
+func pciTestConfigBlob() configBlob {
+	b := make(configBlob, 4096)
+	b.put16(0x00, 0x8086)
+	b.put16(0x02, 0x10c9)
+	b.put16(0x06, 0x0010) // capabilities list present
+	b[0x34] = 0x40
+
+	// Power Management
+	b[0x40], b[0x41] = 0x01, 0x50
+	b.put16(0x42, 0xca03)
+	b.put16(0x44, 0x0008)
+	// MSI
+	b[0x50], b[0x51] = 0x05, 0x70
+	b.put16(0x52, 0x0086)
+	// PCI Express
+	b[0x70], b[0x71] = 0x10, 0xb0
+	b.put16(0x72, 0x0002)
+	b.put32(0x74, 0x10000002)
+	b.put32(0x7c, 0x00000043)
+	b.put16(0x82, 0x0041)
+	// MSI-X
+	b[0xb0], b[0xb1] = 0x11, 0x00
+	b.put16(0xb2, 0x803f)
+	b.put32(0xb4, 0x00002000)
+	b.put32(0xb8, 0x00003002)
+
+	// AER
+	b.putExtHeader(0x100, 0x0001, 2, 0x148)
+	b.put32(0x110, 0x00000040)
+	// ACS
+	b.putExtHeader(0x148, 0x000d, 1, 0x160)
+	b.put16(0x14c, 0x001f)
+	b.put16(0x14e, 0x001d)
+	// SR-IOV
+	b.putExtHeader(0x160, 0x0010, 1, 0x1a0)
+	b.put16(0x168, 0x0001)
+	b.put16(0x16c, 8)
+	b.put16(0x16e, 8)
+	b.put16(0x170, 4)
+	b.put16(0x174, 0x80)
+	b.put16(0x176, 2)
+	b.put16(0x17a, 0x10ca)
+	// ATS
+	b.putExtHeader(0x1a0, 0x000f, 1, 0x1b0)
+	b.put16(0x1a4, 0x0020)
+	b.put16(0x1a6, 0x8000)
+	// PRI
+	b.putExtHeader(0x1b0, 0x0013, 1, 0x1c0)
+	b.put16(0x1b4, 0x0001)
+	b.put32(0x1b8, 0x200)
+	// PASID
+	b.putExtHeader(0x1c0, 0x001b, 1, 0)
+	b.put16(0x1c4, 0x1406)
+	b.put16(0x1c6, 0x0001)
+	return b
+}
+
+func TestParseConfigSpace(t *testing.T) {
+	caps := pci.ParseConfigSpace(pciTestConfigBlob())
+	if caps == nil {
+		t.Fatalf("expected non-nil capabilities")
+	}
+
+	offsets := []int{}
+	for _, capa := range caps.List {
+		offsets = append(offsets, capa.Offset)
+	}
+	expectedOffsets := []int{0x40, 0x50, 0x70, 0xb0, 0x100, 0x148, 0x160, 0x1a0, 0x1b0, 0x1c0}
+	if !reflect.DeepEqual(offsets, expectedOffsets) {
+		t.Fatalf("got capability offsets %#x expected %#x", offsets, expectedOffsets)
+	}
+	if !caps.Has(pci.ExtCapabilityIDACS, true) || caps.Has(pci.ExtCapabilityIDACS, false) {
+		t.Errorf("expected ACS to be listed as extended capability")
+	}
+
+	expectedPM := &pci.PMCapability{Version: 3, D1Support: true, PMESupport: 0x19, NoSoftReset: true}
+	if !reflect.DeepEqual(caps.PowerManagement, expectedPM) {
+		t.Errorf("got PM %+v expected %+v", caps.PowerManagement, expectedPM)
+	}
+	expectedMSI := &pci.MSICapability{MaxVectors: 8, Vectors: 1, Address64: true}
+	if !reflect.DeepEqual(caps.MSI, expectedMSI) {
+		t.Errorf("got MSI %+v expected %+v", caps.MSI, expectedMSI)
+	}
+	expectedMSIX := &pci.MSIXCapability{Enabled: true, TableSize: 64, TableOffset: 0x2000, PBABIR: 2, PBAOffset: 0x3000}
+	if !reflect.DeepEqual(caps.MSIX, expectedMSIX) {
+		t.Errorf("got MSI-X %+v expected %+v", caps.MSIX, expectedMSIX)
+	}
+	expectedExpress := &pci.ExpressCapability{
+		Version:        2,
+		PortType:       pci.ExpressPortTypeEndpoint,
+		MaxPayloadSize: 512,
+		FLR:            true,
+		LinkMaxSpeed:   3,
+		LinkMaxWidth:   4,
+		LinkSpeed:      1,
+		LinkWidth:      4,
+	}
+	if !reflect.DeepEqual(caps.Express, expectedExpress) {
+		t.Errorf("got PCIe %+v expected %+v", caps.Express, expectedExpress)
+	}
+	if caps.AER == nil || caps.AER.CorrectableStatus != 0x40 {
+		t.Errorf("got AER %+v expected correctable status 0x40", caps.AER)
+	}
+	expectedACS := &pci.ACSCapability{Capability: 0x1f, Control: 0x1d}
+	if !reflect.DeepEqual(caps.ACS, expectedACS) {
+		t.Errorf("got ACS %+v expected %+v", caps.ACS, expectedACS)
+	}
+	expectedSRIOV := &pci.SRIOVCapability{
+		VFEnabled:  true,
+		InitialVFs: 8,
+		TotalVFs:   8,
+		NumVFs:     4,
+		VFOffset:   0x80,
+		VFStride:   2,
+		VFDeviceID: 0x10ca,
+	}
+	if !reflect.DeepEqual(caps.SRIOV, expectedSRIOV) {
+		t.Errorf("got SR-IOV %+v expected %+v", caps.SRIOV, expectedSRIOV)
+	}
+	expectedATS := &pci.ATSCapability{Enabled: true, PageAlignedRequest: true}
+	if !reflect.DeepEqual(caps.ATS, expectedATS) {
+		t.Errorf("got ATS %+v expected %+v", caps.ATS, expectedATS)
+	}
+	expectedPRI := &pci.PRICapability{Enabled: true, OutstandingCapacity: 0x200}
+	if !reflect.DeepEqual(caps.PRI, expectedPRI) {
+		t.Errorf("got PRI %+v expected %+v", caps.PRI, expectedPRI)
+	}
+	expectedPASID := &pci.PASIDCapability{Enabled: true, ExecutePermission: true, PrivilegedMode: true, MaxWidth: 20}
+	if !reflect.DeepEqual(caps.PASID, expectedPASID) {
+		t.Errorf("got PASID %+v expected %+v", caps.PASID, expectedPASID)
+	}
+}
+
+func TestParseConfigSpaceTruncated(t *testing.T) {
+	blob := pciTestConfigBlob()
+
+	if caps := pci.ParseConfigSpace(blob[:32]); caps != nil {
+		t.Errorf("expected nil capabilities for a partial header, got %+v", caps)
+	}
+
+	// what unprivileged users get to read
+	caps := pci.ParseConfigSpace(blob[:64])
+	if caps == nil || caps.ConfigSize != 64 || len(caps.List) != 0 {
+		t.Errorf("expected no capabilities from the standard header, got %+v", caps)
+	}
+
+	caps = pci.ParseConfigSpace(blob[:256])
+	if caps == nil || len(caps.List) != 4 || caps.Express == nil || caps.ACS != nil {
+		t.Errorf("expected only standard capabilities from 256 bytes, got %+v", caps)
+	}
+}
+
+func TestParseConfigSpaceLoop(t *testing.T) {
+	blob := pciTestConfigBlob()
+	// make the MSI-X capability point back to the MSI one
+	blob[0xb1] = 0x50
+	// make the PASID capability point back to the AER one
+	blob.putExtHeader(0x1c0, 0x001b, 1, 0x100)
+
+	caps := pci.ParseConfigSpace(blob)
+	if caps == nil || len(caps.List) == 0 || len(caps.List) > 1024 {
+		t.Fatalf("expected bounded capability list, got %+v", caps)
+	}
+}
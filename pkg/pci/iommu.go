@@ -0,0 +1,189 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// IOMMUGroup describes the set of PCI devices the IOMMU cannot isolate from
+// each other. The devices of a group can only be handed to a guest together.
+type IOMMUGroup struct {
+	// The IOMMU group number, as found under /sys/kernel/iommu_groups
+	ID string `json:"id"`
+	// All the PCI devices belonging to the group
+	Devices []*Device `json:"devices"`
+}
+
+func (g *IOMMUGroup) String() string {
+	return fmt.Sprintf("IOMMU group %s (%d devices)", g.ID, len(g.Devices))
+}
+
+// AssignabilityReason describes why an IOMMU group should not be assigned to
+// a guest.
+type AssignabilityReason string
+
+const (
+	// AssignabilityReasonBootDisk indicates the group contains the controller
+	// of the disk holding the host root filesystem
+	AssignabilityReasonBootDisk AssignabilityReason = "boot-disk-controller"
+	// AssignabilityReasonConsole indicates the group contains the UART used
+	// as the host kernel console
+	AssignabilityReasonConsole AssignabilityReason = "console-uart"
+	// AssignabilityReasonOnlyNIC indicates the group contains the only
+	// network controller of the host
+	AssignabilityReasonOnlyNIC AssignabilityReason = "only-nic"
+	// AssignabilityReasonBridge indicates the group contains a host or
+	// PCI-to-PCI bridge
+	AssignabilityReasonBridge AssignabilityReason = "bridge"
+)
+
+// AssignabilityIssue ties an AssignabilityReason to the device causing it.
+type AssignabilityIssue struct {
+	// The PCI address of the offending device
+	Address string              `json:"address"`
+	Reason  AssignabilityReason `json:"reason"`
+}
+
+// AssignableUnit describes whether an IOMMU group can be passed through to a
+// guest as a unit.
+type AssignableUnit struct {
+	Group *IOMMUGroup `json:"group"`
+	// True if none of the group devices is needed by the host
+	Assignable bool `json:"assignable"`
+	// The reasons preventing the assignment, empty if Assignable is true
+	Issues []AssignabilityIssue `json:"issues,omitempty"`
+}
+
+func (u *AssignableUnit) String() string {
+	if u.Assignable {
+		return fmt.Sprintf("%s: assignable", u.Group)
+	}
+	return fmt.Sprintf("%s: not assignable %v", u.Group, u.Issues)
+}
+
+const (
+	classIDBridge             = "06"
+	subclassIDHostBridge      = "00"
+	subclassIDPCIBridge       = "04"
+	subclassIDSemiTransparent = "09"
+	classIDNetwork            = "02"
+)
+
+// IsBridge returns true if the device is a host bridge or a PCI-to-PCI
+// bridge.
+func (d *Device) IsBridge() bool {
+	if d.Class == nil || d.Subclass == nil || d.Class.ID != classIDBridge {
+		return false
+	}
+	switch d.Subclass.ID {
+	case subclassIDHostBridge, subclassIDPCIBridge, subclassIDSemiTransparent:
+		return true
+	}
+	return false
+}
+
+// IOMMUGroups returns the IOMMU groups of the host system, ordered by group
+// number. Devices not belonging to any group are omitted. Returns an empty
+// slice if the IOMMU is disabled.
+func (info *Info) IOMMUGroups() []*IOMMUGroup {
+	byID := map[string]*IOMMUGroup{}
+	for _, dev := range info.Devices {
+		if dev.IOMMUGroup == "" {
+			continue
+		}
+		group, ok := byID[dev.IOMMUGroup]
+		if !ok {
+			group = &IOMMUGroup{ID: dev.IOMMUGroup}
+			byID[dev.IOMMUGroup] = group
+		}
+		group.Devices = append(group.Devices, dev)
+	}
+	groups := make([]*IOMMUGroup, 0, len(byID))
+	for _, group := range byID {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return iommuGroupLess(groups[i].ID, groups[j].ID)
+	})
+	return groups
+}
+
+// GetIOMMUGroup returns the IOMMU group with the given number, or nil if no
+// such group exists.
+func (info *Info) GetIOMMUGroup(id string) *IOMMUGroup {
+	for _, group := range info.IOMMUGroups() {
+		if group.ID == id {
+			return group
+		}
+	}
+	return nil
+}
+
+// AssignableUnits returns, for each IOMMU group, whether the group can be
+// assigned to a guest as a unit.
+func (info *Info) AssignableUnits() []*AssignableUnit {
+	onlyNIC := info.onlyNIC()
+	groups := info.IOMMUGroups()
+	units := make([]*AssignableUnit, 0, len(groups))
+	for _, group := range groups {
+		unit := &AssignableUnit{Group: group}
+		for _, dev := range group.Devices {
+			if dev.HostCritical != "" {
+				unit.Issues = append(unit.Issues, AssignabilityIssue{
+					Address: dev.Address,
+					Reason:  dev.HostCritical,
+				})
+			}
+			if dev.Address == onlyNIC {
+				unit.Issues = append(unit.Issues, AssignabilityIssue{
+					Address: dev.Address,
+					Reason:  AssignabilityReasonOnlyNIC,
+				})
+			}
+			if dev.IsBridge() {
+				unit.Issues = append(unit.Issues, AssignabilityIssue{
+					Address: dev.Address,
+					Reason:  AssignabilityReasonBridge,
+				})
+			}
+		}
+		unit.Assignable = len(unit.Issues) == 0
+		units = append(units, unit)
+	}
+	return units
+}
+
+// onlyNIC returns the address of the network controller if the host has
+// exactly one, not counting SR-IOV virtual functions. Returns an empty string
+// otherwise.
+func (info *Info) onlyNIC() string {
+	nic := ""
+	for _, dev := range info.Devices {
+		if dev.Class == nil || dev.Class.ID != classIDNetwork || dev.IsVirtualFunction() {
+			continue
+		}
+		if nic != "" {
+			return ""
+		}
+		nic = dev.Address
+	}
+	return nic
+}
+
+// iommuGroupLess orders IOMMU group numbers numerically, falling back to
+// lexical order for non-numeric IDs.
+func iommuGroupLess(a, b string) bool {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	if errA != nil || errB != nil {
+		return a < b
+	}
+	return na < nb
+}
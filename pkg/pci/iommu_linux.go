@@ -0,0 +1,118 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zededa/ghw/pkg/console"
+	"github.com/zededa/ghw/pkg/linuxpath"
+	pciaddr "github.com/zededa/ghw/pkg/pci/address"
+)
+
+// getHostCriticalDevices returns the PCI devices the host cannot give away
+// to a guest, keyed by PCI address: the controllers of the disks holding the
+// root filesystem and the UARTs used as kernel console, found the way the
+// serial package finds them.
+func getHostCriticalDevices(paths *linuxpath.Paths) map[string]AssignabilityReason {
+	critical := map[string]AssignabilityReason{}
+	for _, disk := range rootFilesystemDisks(paths) {
+		devPath, err := filepath.EvalSymlinks(filepath.Join(paths.SysBlock, disk))
+		if err != nil {
+			continue
+		}
+		if addr := pciaddr.FromPath(devPath); addr != nil {
+			critical[addr.String()] = AssignabilityReasonBootDisk
+		}
+	}
+	consoles := console.New(paths)
+	ttys, _ := os.ReadDir(paths.SysClassTty)
+	for _, entry := range ttys {
+		tty := entry.Name()
+		if !consoles.IsConsole(paths, tty) {
+			continue
+		}
+		devPath, err := filepath.EvalSymlinks(filepath.Join(paths.SysClassTty, tty, "device"))
+		if err != nil {
+			continue
+		}
+		if addr := pciaddr.FromPath(devPath); addr != nil {
+			critical[addr.String()] = AssignabilityReasonConsole
+		}
+	}
+	return critical
+}
+
+// rootFilesystemDisks returns the names of the whole disks, as listed in
+// /sys/block, backing the filesystem mounted on /.
+func rootFilesystemDisks(paths *linuxpath.Paths) []string {
+	f, err := os.Open(paths.ProcMounts)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	source := ""
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		// the last mount on / is the one visible
+		if len(fields) >= 2 && fields[1] == "/" {
+			source = fields[0]
+		}
+	}
+	if !strings.HasPrefix(source, "/dev/") {
+		return nil
+	}
+	name := filepath.Base(source)
+	if strings.HasPrefix(source, "/dev/mapper/") {
+		name = dmDeviceByName(paths, name)
+	}
+	return blockDeviceDisks(paths, name, 0)
+}
+
+// dmDeviceByName returns the dm-N kernel name of the device mapper device
+// with the given name, or the name unchanged if not found.
+func dmDeviceByName(paths *linuxpath.Paths, name string) string {
+	dmNames, _ := filepath.Glob(filepath.Join(paths.SysBlock, "dm-*", "dm", "name"))
+	for _, dmName := range dmNames {
+		if readDeviceString(dmName) == name {
+			return filepath.Base(filepath.Dir(filepath.Dir(dmName)))
+		}
+	}
+	return name
+}
+
+// blockDeviceDisks returns the whole disks backing the given block device,
+// following partitions to their disk and stacked devices (device mapper, md)
+// to their slaves.
+func blockDeviceDisks(paths *linuxpath.Paths, name string, depth int) []string {
+	// guard against loops in broken trees
+	if name == "" || depth > 8 {
+		return nil
+	}
+	diskDir := filepath.Join(paths.SysBlock, name)
+	if _, err := os.Stat(diskDir); err != nil {
+		// not a disk, maybe a partition
+		parts, _ := filepath.Glob(filepath.Join(paths.SysBlock, "*", name))
+		if len(parts) == 0 {
+			return nil
+		}
+		return blockDeviceDisks(paths, filepath.Base(filepath.Dir(parts[0])), depth+1)
+	}
+	slaves, _ := os.ReadDir(filepath.Join(diskDir, "slaves"))
+	if len(slaves) == 0 {
+		return []string{name}
+	}
+	var disks []string
+	for _, slave := range slaves {
+		disks = append(disks, blockDeviceDisks(paths, slave.Name(), depth+1)...)
+	}
+	return disks
+}
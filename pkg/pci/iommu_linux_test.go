@@ -0,0 +1,110 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zededa/ghw/pkg/pci"
+)
+
+func iommuGroupLink(id string) map[string]string {
+	return map[string]string{
+		"iommu_group": "../../../kernel/iommu_groups/" + id,
+	}
+}
+
+func TestPCIIOMMUGroups(t *testing.T) {
+	root := pciTestSetupFakeSysfs(t, []pciTestDevice{
+		{
+			addr:     "0000:00:00.0",
+			modalias: "pci:v00008086d00004660sv00008086sd00007270bc06sc00i00\n",
+			links:    iommuGroupLink("0"),
+		},
+		{
+			addr:     "0000:00:01.0",
+			modalias: "pci:v00008086d0000460Dsv00008086sd00007270bc06sc04i00\n",
+			links:    iommuGroupLink("1"),
+		},
+		{
+			addr:       "0000:01:00.0",
+			parentAddr: "0000:00:01.0",
+			modalias:   "pci:v0000144Dd0000A808sv0000144Dsd0000A801bc01sc08i02\n",
+			links:      iommuGroupLink("10"),
+		},
+		{
+			addr:     "0000:00:16.3",
+			modalias: "pci:v00008086d00007AEBsv00008086sd00007270bc07sc00i02\n",
+			links:    iommuGroupLink("2"),
+		},
+		{
+			addr:     "0000:00:16.0",
+			modalias: "pci:v00008086d00007AE8sv00008086sd00007270bc07sc80i00\n",
+			links:    iommuGroupLink("2"),
+		},
+		{
+			addr:     "0000:02:00.0",
+			modalias: "pci:v00008086d000015F3sv00008086sd00000000bc02sc00i00\n",
+			links:    iommuGroupLink("3"),
+		},
+		{
+			addr:     "0000:03:00.0",
+			modalias: "pci:v000010DEd00001C82sv00001043sd00008613bc03sc00i00\n",
+			links:    iommuGroupLink("4"),
+		},
+		{
+			addr:     "0000:03:00.1",
+			modalias: "pci:v000010DEd00000FB9sv00001043sd00008613bc04sc03i00\n",
+			links:    iommuGroupLink("4"),
+		},
+	})
+	pciTestWriteFile(t, root, "proc/self/mounts", "rootfs / rootfs rw 0 0\n/dev/nvme0n1p2 / ext4 rw,relatime 0 0\n")
+	pciTestSymlink(t, root, "sys/block/nvme0n1", "../devices/pci0000:00/0000:00:01.0/0000:01:00.0/nvme/nvme0/nvme0n1")
+	pciTestWriteFile(t, root, "sys/devices/pci0000:00/0000:00:01.0/0000:01:00.0/nvme/nvme0/nvme0n1/nvme0n1p2/partition", "2\n")
+	// the AMT serial-over-LAN UART, a console by IO address
+	pciTestWriteFile(t, root, "proc/cmdline", "root=/dev/nvme0n1p2 console=tty0 console=uart8250,io,0xf0a0,115200n8\n")
+	pciTestWriteFile(t, root, "sys/class/tty/ttyS4/io_type", "0\n")
+	pciTestWriteFile(t, root, "sys/class/tty/ttyS4/port", "0xF0A0\n")
+	pciTestSymlink(t, root, "sys/class/tty/ttyS4/device", "../../../devices/pci0000:00/0000:00:16.3")
+
+	info := pciTestLoad(t, root)
+
+	groups := info.IOMMUGroups()
+	groupIDs := []string{}
+	for _, group := range groups {
+		groupIDs = append(groupIDs, group.ID)
+	}
+	expectedIDs := []string{"0", "1", "2", "3", "4", "10"}
+	if !reflect.DeepEqual(groupIDs, expectedIDs) {
+		t.Fatalf("got IOMMU groups %v expected %v", groupIDs, expectedIDs)
+	}
+	if group := info.GetIOMMUGroup("4"); group == nil || len(group.Devices) != 2 {
+		t.Fatalf("expected 2 devices in IOMMU group 4, got %v", group)
+	}
+	if dev := info.GetDevice("0000:01:00.0"); dev.HostCritical != pci.AssignabilityReasonBootDisk {
+		t.Fatalf("expected the NVMe controller to be host critical, got %q", dev.HostCritical)
+	}
+
+	expected := map[string][]pci.AssignabilityIssue{
+		"0":  {{Address: "0000:00:00.0", Reason: pci.AssignabilityReasonBridge}},
+		"1":  {{Address: "0000:00:01.0", Reason: pci.AssignabilityReasonBridge}},
+		"2":  {{Address: "0000:00:16.3", Reason: pci.AssignabilityReasonConsole}},
+		"3":  {{Address: "0000:02:00.0", Reason: pci.AssignabilityReasonOnlyNIC}},
+		"4":  nil,
+		"10": {{Address: "0000:01:00.0", Reason: pci.AssignabilityReasonBootDisk}},
+	}
+	for _, unit := range info.AssignableUnits() {
+		issues := expected[unit.Group.ID]
+		if !reflect.DeepEqual(unit.Issues, issues) {
+			t.Errorf("group %s: got issues %v expected %v", unit.Group.ID, unit.Issues, issues)
+		}
+		if unit.Assignable != (len(issues) == 0) {
+			t.Errorf("group %s: got assignable %v", unit.Group.ID, unit.Assignable)
+		}
+	}
+}
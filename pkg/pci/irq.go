@@ -0,0 +1,241 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// InterruptType tells how a PCI device signals an interrupt.
+type InterruptType string
+
+const (
+	// InterruptTypeLegacy indicates a legacy INTx interrupt line, possibly
+	// shared with other devices
+	InterruptTypeLegacy InterruptType = "legacy"
+	// InterruptTypeMSI indicates a Message Signaled Interrupt vector
+	InterruptTypeMSI InterruptType = "msi"
+	// InterruptTypeMSIX indicates an MSI-X vector
+	InterruptTypeMSIX InterruptType = "msix"
+)
+
+// Interrupt describes an interrupt of a PCI device, and where the kernel
+// delivers it.
+type Interrupt struct {
+	// The Linux IRQ number
+	IRQ  int           `json:"irq"`
+	Type InterruptType `json:"type"`
+	// The interrupt description from /proc/interrupts: the interrupt
+	// controller, hardware IRQ, trigger type and handler names, e.g.
+	// "IR-PCI-MSI 512000-edge ahci[0000:00:17.0]"
+	Description string `json:"description,omitempty"`
+	// The number of interrupts each CPU handled, keyed by logical CPU ID.
+	// Offline CPUs are omitted.
+	Counts map[int]uint64 `json:"counts,omitempty"`
+	// The logical CPUs the interrupt may be delivered to, from
+	// /proc/irq/<irq>/smp_affinity_list
+	Affinity []int `json:"affinity,omitempty"`
+	// The logical CPUs the interrupt is actually delivered to, when the
+	// kernel reports it
+	EffectiveAffinity []int `json:"effective_affinity,omitempty"`
+}
+
+func (irq *Interrupt) String() string {
+	return fmt.Sprintf(
+		"IRQ %d (%s) total: %d affinity: %s",
+		irq.IRQ,
+		irq.Type,
+		irq.Total(),
+		formatCPUList(irq.Affinity),
+	)
+}
+
+// Total returns the number of interrupts handled across all CPUs.
+func (irq *Interrupt) Total() uint64 {
+	total := uint64(0)
+	for _, count := range irq.Counts {
+		total += count
+	}
+	return total
+}
+
+// LandsOn returns true if the interrupt may be delivered to, or has already
+// been handled by, any of the given logical CPUs. Use it to check device
+// interrupts stay away from CPUs isolated for real-time workloads.
+func (irq *Interrupt) LandsOn(cpus ...int) bool {
+	for _, cpu := range cpus {
+		if irq.Counts[cpu] > 0 {
+			return true
+		}
+		for _, aff := range irq.targets() {
+			if aff == cpu {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// targets returns the effective affinity if known, the affinity otherwise
+func (irq *Interrupt) targets() []int {
+	if len(irq.EffectiveAffinity) > 0 {
+		return irq.EffectiveAffinity
+	}
+	return irq.Affinity
+}
+
+// InterruptsOn returns the interrupts of the device which may be delivered
+// to, or have already been handled by, any of the given logical CPUs.
+func (d *Device) InterruptsOn(cpus ...int) []*Interrupt {
+	irqs := []*Interrupt{}
+	for _, irq := range d.Interrupts {
+		if irq.LandsOn(cpus...) {
+			irqs = append(irqs, irq)
+		}
+	}
+	return irqs
+}
+
+// RemoteInterrupts returns the interrupts of the device which may be
+// delivered to CPUs outside of the NUMA node the device is attached to.
+// Returns nil if the CPUs local to the device are unknown.
+func (d *Device) RemoteInterrupts() []*Interrupt {
+	if len(d.LocalCPUs) == 0 {
+		return nil
+	}
+	local := make(map[int]bool, len(d.LocalCPUs))
+	for _, cpu := range d.LocalCPUs {
+		local[cpu] = true
+	}
+	irqs := []*Interrupt{}
+	for _, irq := range d.Interrupts {
+		for _, cpu := range irq.targets() {
+			if !local[cpu] {
+				irqs = append(irqs, irq)
+				break
+			}
+		}
+	}
+	return irqs
+}
+
+// interruptRow is a row of /proc/interrupts
+type interruptRow struct {
+	counts      map[int]uint64
+	description string
+}
+
+// parseProcInterrupts parses the content of /proc/interrupts, returning the
+// rows of numbered IRQs keyed by IRQ number. Rows of architecture specific
+// interrupts such as NMI or LOC are skipped.
+func parseProcInterrupts(data []byte) map[int]*interruptRow {
+	rows := map[int]*interruptRow{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	if !scanner.Scan() {
+		return rows
+	}
+	// the header lists the online CPUs, e.g. "CPU0 CPU1 CPU3"
+	cpus := []int{}
+	for _, field := range strings.Fields(scanner.Text()) {
+		cpu, err := strconv.Atoi(strings.TrimPrefix(field, "CPU"))
+		if err != nil {
+			return rows
+		}
+		cpus = append(cpus, cpu)
+	}
+	for scanner.Scan() {
+		label, rest, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		irq, err := strconv.Atoi(strings.TrimSpace(label))
+		if err != nil {
+			continue
+		}
+		fields := strings.Fields(rest)
+		row := &interruptRow{counts: make(map[int]uint64, len(cpus))}
+		idx := 0
+		for ; idx < len(fields) && idx < len(cpus); idx++ {
+			count, err := strconv.ParseUint(fields[idx], 10, 64)
+			if err != nil {
+				break
+			}
+			row.counts[cpus[idx]] = count
+		}
+		row.description = strings.Join(fields[idx:], " ")
+		rows[irq] = row
+	}
+	return rows
+}
+
+// parseCPUList parses a CPU list such as "0-3,8,10-11", as found in
+// smp_affinity_list or local_cpulist files.
+func parseCPUList(list string) []int {
+	var cpus []int
+	for _, part := range strings.Split(strings.TrimSpace(list), ",") {
+		if part == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			return nil
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(last); err != nil || end < start {
+				return nil
+			}
+		}
+		for cpu := start; cpu <= end; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus
+}
+
+// parseCPUMask parses a hexadecimal CPU mask such as "00000000,0000000f", as
+// found in smp_affinity files.
+func parseCPUMask(mask string) []int {
+	words := strings.Split(strings.TrimSpace(mask), ",")
+	var cpus []int
+	for idx := range words {
+		// the least significant word comes last
+		word, err := strconv.ParseUint(words[len(words)-1-idx], 16, 32)
+		if err != nil {
+			return nil
+		}
+		for bit := 0; bit < 32; bit++ {
+			if word&(1<<bit) != 0 {
+				cpus = append(cpus, idx*32+bit)
+			}
+		}
+	}
+	return cpus
+}
+
+// formatCPUList renders CPU IDs in the "0-3,8" format used by the kernel
+func formatCPUList(cpus []int) string {
+	parts := []string{}
+	for idx := 0; idx < len(cpus); {
+		end := idx
+		for end+1 < len(cpus) && cpus[end+1] == cpus[end]+1 {
+			end++
+		}
+		if end == idx {
+			parts = append(parts, strconv.Itoa(cpus[idx]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", cpus[idx], cpus[end]))
+		}
+		idx = end + 1
+	}
+	return strings.Join(parts, ",")
+}
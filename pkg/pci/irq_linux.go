@@ -0,0 +1,94 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/zededa/ghw/pkg/linuxpath"
+	pciaddr "github.com/zededa/ghw/pkg/pci/address"
+)
+
+// getProcInterrupts returns the rows of /proc/interrupts keyed by IRQ number
+func getProcInterrupts(paths *linuxpath.Paths) map[int]*interruptRow {
+	data, err := os.ReadFile(paths.ProcInterrupts)
+	if err != nil {
+		return map[int]*interruptRow{}
+	}
+	return parseProcInterrupts(data)
+}
+
+// getDeviceIRQ returns the legacy interrupt line of the device at the given
+// address, or 0 if the device has none. Note the kernel reports the first MSI
+// vector instead while MSI is enabled.
+func getDeviceIRQ(paths *linuxpath.Paths, pciAddr *pciaddr.Address) int {
+	irq, _ := readDeviceInt(filepath.Join(paths.SysBusPciDevices, pciAddr.String(), "irq"))
+	return irq
+}
+
+// getDeviceInterrupts returns the MSI and MSI-X vectors allocated by the
+// device at the given address, from its msi_irqs directory. Devices using
+// neither report their legacy interrupt line, if any.
+func getDeviceInterrupts(
+	paths *linuxpath.Paths,
+	pciAddr *pciaddr.Address,
+	legacyIRQ int,
+	rows map[int]*interruptRow,
+) []*Interrupt {
+	msiPath := filepath.Join(paths.SysBusPciDevices, pciAddr.String(), "msi_irqs")
+
+	irqs := []*Interrupt{}
+	entries, _ := os.ReadDir(msiPath)
+	for _, entry := range entries {
+		num, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		irqType := InterruptTypeMSI
+		if readDeviceString(filepath.Join(msiPath, entry.Name())) == "msix" {
+			irqType = InterruptTypeMSIX
+		}
+		irqs = append(irqs, newInterrupt(paths, num, irqType, rows))
+	}
+	if len(irqs) == 0 && legacyIRQ > 0 {
+		irqs = append(irqs, newInterrupt(paths, legacyIRQ, InterruptTypeLegacy, rows))
+	}
+	if len(irqs) == 0 {
+		return nil
+	}
+	sort.Slice(irqs, func(i, j int) bool {
+		return irqs[i].IRQ < irqs[j].IRQ
+	})
+	return irqs
+}
+
+func newInterrupt(paths *linuxpath.Paths, num int, irqType InterruptType, rows map[int]*interruptRow) *Interrupt {
+	irqPath := filepath.Join(paths.ProcIrq, strconv.Itoa(num))
+
+	irq := &Interrupt{
+		IRQ:               num,
+		Type:              irqType,
+		Affinity:          parseCPUList(readDeviceString(filepath.Join(irqPath, "smp_affinity_list"))),
+		EffectiveAffinity: parseCPUList(readDeviceString(filepath.Join(irqPath, "effective_affinity_list"))),
+	}
+	if len(irq.Affinity) == 0 {
+		irq.Affinity = parseCPUMask(readDeviceString(filepath.Join(irqPath, "smp_affinity")))
+	}
+	if row, ok := rows[num]; ok {
+		irq.Counts = row.counts
+		irq.Description = row.description
+	}
+	return irq
+}
+
+// getDeviceLocalCPUs returns the logical CPUs of the NUMA node the device at
+// the given address is attached to
+func getDeviceLocalCPUs(paths *linuxpath.Paths, pciAddr *pciaddr.Address) []int {
+	return parseCPUList(readDeviceString(filepath.Join(paths.SysBusPciDevices, pciAddr.String(), "local_cpulist")))
+}
@@ -0,0 +1,121 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zededa/ghw/pkg/pci"
+)
+
+const irqTestProcInterrupts = `            CPU0       CPU1       CPU2       CPU3
+   0:         36          0          0          0   IO-APIC   2-edge      timer
+  16:          0          0        120          0   IO-APIC  16-fasteoi   i801_smbus
+ 124:          0       5000          0          0  IR-PCI-MSI 1048576-edge      enp2s0
+ 125:          0          0          0        321  IR-PCI-MSI 1048577-edge      enp2s0-rx-0
+ 126:          0          0          0          0  IR-PCI-MSI 1048578-edge      enp2s0-tx-0
+ NMI:          0          0          0          0   Non-maskable interrupts
+ ERR:          0
+`
+
+func TestPCIInterrupts(t *testing.T) {
+	root := pciTestSetupFakeSysfs(t, []pciTestDevice{
+		{
+			addr: "0000:00:1f.4",
+			attrs: map[string]string{
+				"irq":           "16\n",
+				"local_cpulist": "0-3\n",
+			},
+		},
+		{
+			addr: "0000:02:00.0",
+			attrs: map[string]string{
+				"irq":           "17\n",
+				"local_cpulist": "0-1\n",
+				"msi_irqs/124":  "msix\n",
+				"msi_irqs/125":  "msix\n",
+				"msi_irqs/126":  "msix\n",
+			},
+		},
+		{
+			addr: "0000:00:02.0",
+		},
+	})
+	pciTestWriteFile(t, root, "proc/interrupts", irqTestProcInterrupts)
+	pciTestWriteFile(t, root, "proc/irq/16/smp_affinity_list", "0-3\n")
+	pciTestWriteFile(t, root, "proc/irq/124/smp_affinity_list", "1\n")
+	pciTestWriteFile(t, root, "proc/irq/125/smp_affinity_list", "2-3\n")
+	pciTestWriteFile(t, root, "proc/irq/125/effective_affinity_list", "3\n")
+	// kernels without smp_affinity_list
+	pciTestWriteFile(t, root, "proc/irq/126/smp_affinity", "00000000,00000001\n")
+
+	info := pciTestLoad(t, root)
+
+	smbus := info.GetDevice("0000:00:1f.4")
+	if smbus == nil {
+		t.Fatalf("expected device 0000:00:1f.4")
+	}
+	expected := []*pci.Interrupt{
+		{
+			IRQ:         16,
+			Type:        pci.InterruptTypeLegacy,
+			Description: "IO-APIC 16-fasteoi i801_smbus",
+			Counts:      map[int]uint64{0: 0, 1: 0, 2: 120, 3: 0},
+			Affinity:    []int{0, 1, 2, 3},
+		},
+	}
+	if smbus.IRQ != 16 || !reflect.DeepEqual(smbus.Interrupts, expected) {
+		t.Errorf("unexpected legacy interrupt %d %v", smbus.IRQ, smbus.Interrupts)
+	}
+
+	nic := info.GetDevice("0000:02:00.0")
+	if nic == nil {
+		t.Fatalf("expected device 0000:02:00.0")
+	}
+	if len(nic.Interrupts) != 3 {
+		t.Fatalf("expected 3 MSI-X vectors, got %v", nic.Interrupts)
+	}
+	tCases := []struct {
+		irq       int
+		total     uint64
+		affinity  []int
+		effective []int
+	}{
+		{irq: 124, total: 5000, affinity: []int{1}},
+		{irq: 125, total: 321, affinity: []int{2, 3}, effective: []int{3}},
+		{irq: 126, total: 0, affinity: []int{0}},
+	}
+	for idx, tCase := range tCases {
+		irq := nic.Interrupts[idx]
+		if irq.IRQ != tCase.irq || irq.Type != pci.InterruptTypeMSIX {
+			t.Errorf("got IRQ %d (%s) expected %d (msix)", irq.IRQ, irq.Type, tCase.irq)
+		}
+		if irq.Total() != tCase.total {
+			t.Errorf("IRQ %d: got total %d expected %d", irq.IRQ, irq.Total(), tCase.total)
+		}
+		if !reflect.DeepEqual(irq.Affinity, tCase.affinity) || !reflect.DeepEqual(irq.EffectiveAffinity, tCase.effective) {
+			t.Errorf("IRQ %d: got affinity %v/%v expected %v/%v",
+				irq.IRQ, irq.Affinity, irq.EffectiveAffinity, tCase.affinity, tCase.effective)
+		}
+	}
+
+	// CPU 3 is isolated: only the vector effectively delivered there lands
+	// on it
+	isolated := nic.InterruptsOn(3)
+	if len(isolated) != 1 || isolated[0].IRQ != 125 {
+		t.Errorf("expected IRQ 125 to land on CPU 3, got %v", isolated)
+	}
+	remote := nic.RemoteInterrupts()
+	if len(remote) != 1 || remote[0].IRQ != 125 {
+		t.Errorf("expected IRQ 125 to be remote, got %v", remote)
+	}
+
+	if dev := info.GetDevice("0000:00:02.0"); dev.IRQ != 0 || dev.Interrupts != nil {
+		t.Errorf("expected no interrupts, got %d %v", dev.IRQ, dev.Interrupts)
+	}
+}
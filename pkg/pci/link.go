@@ -0,0 +1,92 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PCIeLink describes the negotiated and the maximum capabilities of the PCI
+// Express link of a device.
+type PCIeLink struct {
+	// Negotiated link speed as reported by the kernel, e.g. "8.0 GT/s PCIe"
+	CurrentSpeed string `json:"current_speed"`
+	// Negotiated number of lanes
+	CurrentWidth int `json:"current_width"`
+	// Maximum link speed supported by the device, e.g. "16.0 GT/s PCIe"
+	MaxSpeed string `json:"max_speed"`
+	// Maximum number of lanes supported by the device
+	MaxWidth int `json:"max_width"`
+	// True if the link trained below the maximum speed or width. Note some
+	// devices, most notably GPUs, lower the link speed when idle to save
+	// power.
+	Downgraded bool `json:"downgraded"`
+}
+
+func (l *PCIeLink) String() string {
+	str := fmt.Sprintf(
+		"PCIe link %s x%d (max %s x%d)",
+		l.CurrentSpeed,
+		l.CurrentWidth,
+		l.MaxSpeed,
+		l.MaxWidth,
+	)
+	if l.Downgraded {
+		str += " downgraded"
+	}
+	return str
+}
+
+// CurrentGeneration returns the PCIe generation matching the negotiated link
+// speed, or 0 if unknown.
+func (l *PCIeLink) CurrentGeneration() int {
+	return linkSpeedGeneration(linkSpeedGTs(l.CurrentSpeed))
+}
+
+// MaxGeneration returns the PCIe generation matching the maximum link speed,
+// or 0 if unknown.
+func (l *PCIeLink) MaxGeneration() int {
+	return linkSpeedGeneration(linkSpeedGTs(l.MaxSpeed))
+}
+
+// isDowngraded returns true if the link trained below its maximum speed or
+// width. Unknown values never count as a downgrade.
+func (l *PCIeLink) isDowngraded() bool {
+	curGTs := linkSpeedGTs(l.CurrentSpeed)
+	maxGTs := linkSpeedGTs(l.MaxSpeed)
+	if curGTs > 0 && maxGTs > 0 && curGTs < maxGTs {
+		return true
+	}
+	return l.CurrentWidth > 0 && l.MaxWidth > 0 && l.CurrentWidth < l.MaxWidth
+}
+
+// linkSpeedGTs parses a link speed string like "8.0 GT/s PCIe" or "2.5 GT/s"
+// returning the transfer rate in GT/s, or 0 if the string cannot be parsed.
+func linkSpeedGTs(speed string) float64 {
+	fields := strings.Fields(speed)
+	if len(fields) < 2 || fields[1] != "GT/s" {
+		return 0
+	}
+	val, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0
+	}
+	return val
+}
+
+var linkGenerationSpeeds = []float64{2.5, 5, 8, 16, 32, 64}
+
+func linkSpeedGeneration(gts float64) int {
+	for idx, speed := range linkGenerationSpeeds {
+		if gts == speed {
+			return idx + 1
+		}
+	}
+	return 0
+}
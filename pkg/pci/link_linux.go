@@ -0,0 +1,32 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"path/filepath"
+
+	"github.com/zededa/ghw/pkg/linuxpath"
+	pciaddr "github.com/zededa/ghw/pkg/pci/address"
+)
+
+// getDevicePCIeLink returns the PCIe link information of the device at the
+// given address, or nil if the device is not PCI Express.
+func getDevicePCIeLink(paths *linuxpath.Paths, pciAddr *pciaddr.Address) *PCIeLink {
+	devPath := filepath.Join(paths.SysBusPciDevices, pciAddr.String())
+
+	link := &PCIeLink{
+		CurrentSpeed: readDeviceString(filepath.Join(devPath, "current_link_speed")),
+		MaxSpeed:     readDeviceString(filepath.Join(devPath, "max_link_speed")),
+	}
+	link.CurrentWidth, _ = readDeviceInt(filepath.Join(devPath, "current_link_width"))
+	link.MaxWidth, _ = readDeviceInt(filepath.Join(devPath, "max_link_width"))
+	// conventional PCI devices expose none of the link attributes
+	if link.CurrentSpeed == "" && link.MaxSpeed == "" && link.CurrentWidth == 0 && link.MaxWidth == 0 {
+		return nil
+	}
+	link.Downgraded = link.isDowngraded()
+	return link
+}
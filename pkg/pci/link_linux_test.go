@@ -0,0 +1,84 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci_test
+
+import (
+	"testing"
+)
+
+func TestPCIeLink(t *testing.T) {
+	info := pciTestSetupFake(t, []pciTestDevice{
+		{
+			addr: "0000:00:1c.0",
+		},
+		{
+			addr:       "0000:01:00.0",
+			parentAddr: "0000:00:1c.0",
+			attrs: map[string]string{
+				"current_link_speed": "8.0 GT/s PCIe\n",
+				"current_link_width": "4\n",
+				"max_link_speed":     "8.0 GT/s PCIe\n",
+				"max_link_width":     "4\n",
+			},
+		},
+		{
+			addr:       "0000:02:00.0",
+			parentAddr: "0000:00:1c.0",
+			attrs: map[string]string{
+				"current_link_speed": "2.5 GT/s\n",
+				"current_link_width": "1\n",
+				"max_link_speed":     "16.0 GT/s PCIe\n",
+				"max_link_width":     "16\n",
+			},
+		},
+		{
+			addr:       "0000:03:00.0",
+			parentAddr: "0000:00:1c.0",
+			attrs: map[string]string{
+				"current_link_speed": "Unknown\n",
+				"current_link_width": "0\n",
+				"max_link_speed":     "5.0 GT/s PCIe\n",
+				"max_link_width":     "1\n",
+			},
+		},
+	})
+
+	tCases := []struct {
+		addr       string
+		curGen     int
+		maxGen     int
+		curWidth   int
+		maxWidth   int
+		downgraded bool
+	}{
+		{addr: "0000:01:00.0", curGen: 3, maxGen: 3, curWidth: 4, maxWidth: 4},
+		{addr: "0000:02:00.0", curGen: 1, maxGen: 4, curWidth: 1, maxWidth: 16, downgraded: true},
+		{addr: "0000:03:00.0", curGen: 0, maxGen: 2, curWidth: 0, maxWidth: 1},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.addr, func(t *testing.T) {
+			dev := info.GetDevice(tCase.addr)
+			if dev == nil || dev.Link == nil {
+				t.Fatalf("expected PCIe link info for %q", tCase.addr)
+			}
+			link := dev.Link
+			if link.CurrentGeneration() != tCase.curGen || link.MaxGeneration() != tCase.maxGen {
+				t.Errorf("got gen %d/%d expected %d/%d", link.CurrentGeneration(), link.MaxGeneration(), tCase.curGen, tCase.maxGen)
+			}
+			if link.CurrentWidth != tCase.curWidth || link.MaxWidth != tCase.maxWidth {
+				t.Errorf("got width x%d/x%d expected x%d/x%d", link.CurrentWidth, link.MaxWidth, tCase.curWidth, tCase.maxWidth)
+			}
+			if link.Downgraded != tCase.downgraded {
+				t.Errorf("got downgraded %v expected %v", link.Downgraded, tCase.downgraded)
+			}
+		})
+	}
+
+	if dev := info.GetDevice("0000:00:1c.0"); dev.Link != nil {
+		t.Errorf("expected no PCIe link info for %q, got %v", dev.Address, dev.Link)
+	}
+}
@@ -40,6 +40,45 @@ type Device struct {
 	// for IOMMU Groups see also:
 	// https://docs.redhat.com/en/documentation/red_hat_enterprise_linux/7/html/virtualization_deployment_and_administration_guide/sect-iommu-deep-dive
 	IOMMUGroup string `json:"iommu_group"`
+	// SR-IOV configuration of the device, if it is a physical function.
+	// Will be nil if the device is not SR-IOV capable.
+	SRIOV *SRIOV `json:"sriov,omitempty"`
+	// The PCI address of the SR-IOV physical function which spawned this
+	// device, if it is a virtual function
+	PhysicalFunction string `json:"physical_function,omitempty"`
+	// PCI Express link status. Will be nil for conventional PCI devices.
+	Link *PCIeLink `json:"link,omitempty"`
+	// Address regions (BARs, expansion ROM, bridge windows) decoded by the
+	// device
+	Resources []Resource `json:"resources,omitempty"`
+	// Capabilities decoded from the device configuration space. Will be nil
+	// if the configuration space could not be read.
+	Capabilities *Capabilities `json:"capabilities,omitempty"`
+	// Reset methods supported by the device
+	Reset *Reset `json:"reset,omitempty"`
+	// The physical slot holding the device. Will be nil for devices
+	// soldered on the motherboard, or if the slot is unknown.
+	Slot *Slot `json:"slot,omitempty"`
+	// PCI Express Advanced Error Reporting counters. Will be nil if the
+	// kernel does not report AER for the device.
+	AER *AERStats `json:"aer,omitempty"`
+	// The legacy interrupt line of the device, 0 if it has none
+	IRQ int `json:"irq,omitempty"`
+	// The MSI and MSI-X vectors of the device or, if it uses neither, its
+	// legacy interrupt
+	Interrupts []*Interrupt `json:"interrupts,omitempty"`
+	// The logical CPUs local to the device, i.e. belonging to the NUMA node
+	// it is attached to
+	LocalCPUs []int `json:"local_cpus,omitempty"`
+	// Vital Product Data of the device, such as the board part and serial
+	// numbers. Will be nil if the device has none, if it could not be read,
+	// or unless requested with option.WithPCIVPD.
+	VPD *VPD `json:"vpd,omitempty"`
+	// Power management state of the device
+	Power *Power `json:"power,omitempty"`
+	// Why the host cannot run without the device, e.g. because it controls
+	// the disk holding the root filesystem. Empty if it can.
+	HostCritical AssignabilityReason `json:"host_critical,omitempty"`
 }
 
 type devIdent struct {
@@ -48,17 +87,31 @@ type devIdent struct {
 }
 
 type devMarshallable struct {
-	Driver        string   `json:"driver"`
-	Address       string   `json:"address"`
-	ParentAddress string   `json:"parent_address"`
-	Vendor        devIdent `json:"vendor"`
-	Product       devIdent `json:"product"`
-	Revision      string   `json:"revision"`
-	Subsystem     devIdent `json:"subsystem"`
-	Class         devIdent `json:"class"`
-	Subclass      devIdent `json:"subclass"`
-	Interface     devIdent `json:"programming_interface"`
-	IOMMUGroup    string   `json:"iommu_group"`
+	Driver        string              `json:"driver"`
+	Address       string              `json:"address"`
+	ParentAddress string              `json:"parent_address"`
+	Vendor        devIdent            `json:"vendor"`
+	Product       devIdent            `json:"product"`
+	Revision      string              `json:"revision"`
+	Subsystem     devIdent            `json:"subsystem"`
+	Class         devIdent            `json:"class"`
+	Subclass      devIdent            `json:"subclass"`
+	Interface     devIdent            `json:"programming_interface"`
+	IOMMUGroup    string              `json:"iommu_group"`
+	SRIOV         *SRIOV              `json:"sriov,omitempty"`
+	PhysFn        string              `json:"physical_function,omitempty"`
+	Link          *PCIeLink           `json:"link,omitempty"`
+	Resources     []Resource          `json:"resources,omitempty"`
+	Capabilities  *Capabilities       `json:"capabilities,omitempty"`
+	Reset         *Reset              `json:"reset,omitempty"`
+	Slot          *Slot               `json:"slot,omitempty"`
+	AER           *AERStats           `json:"aer,omitempty"`
+	IRQ           int                 `json:"irq,omitempty"`
+	Interrupts    []*Interrupt        `json:"interrupts,omitempty"`
+	LocalCPUs     []int               `json:"local_cpus,omitempty"`
+	VPD           *VPD                `json:"vpd,omitempty"`
+	Power         *Power              `json:"power,omitempty"`
+	HostCritical  AssignabilityReason `json:"host_critical,omitempty"`
 }
 
 // NOTE(jaypipes) Device has a custom JSON marshaller because we don't want
@@ -95,7 +148,21 @@ func (d *Device) MarshalJSON() ([]byte, error) {
 			ID:   d.ProgrammingInterface.ID,
 			Name: d.ProgrammingInterface.Name,
 		},
-		IOMMUGroup: d.IOMMUGroup,
+		IOMMUGroup:   d.IOMMUGroup,
+		SRIOV:        d.SRIOV,
+		PhysFn:       d.PhysicalFunction,
+		Link:         d.Link,
+		Resources:    d.Resources,
+		Capabilities: d.Capabilities,
+		Reset:        d.Reset,
+		Slot:         d.Slot,
+		AER:          d.AER,
+		IRQ:          d.IRQ,
+		Interrupts:   d.Interrupts,
+		LocalCPUs:    d.LocalCPUs,
+		VPD:          d.VPD,
+		Power:        d.Power,
+		HostCritical: d.HostCritical,
 	}
 	return json.Marshal(dm)
 }
@@ -128,6 +195,13 @@ type Info struct {
 	arch topology.Architecture
 	// All PCI devices on the host system
 	Devices []*Device
+	// All physical PCI slots of the host system, empty or not
+	Slots []*Slot `json:",omitempty"`
+	// The release date of the PCI ID database used to resolve vendor,
+	// product and class names, e.g. "2022-01-22". Empty if unknown.
+	DBDate string `json:",omitempty"`
+	// Where the PCI ID database came from
+	DBSource PCIDBSource `json:",omitempty"`
 }
 
 func (i *Info) String() string {
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/jaypipes/pcidb"
@@ -26,18 +27,16 @@ const (
 )
 
 func (i *Info) load(opts *option.Options) error {
-	pcidbOpt := &pcidb.WithOption{}
-	if path := os.Getenv("PCIDB_PATH"); path != "" {
-		pcidbOpt = pcidb.WithPath(path)
-	}
-	if i.db == nil {
-		db, err := pcidb.New(pcidbOpt)
-		if err != nil {
-			return err
-		}
-		i.db = db
+	if err := i.loadPCIDB(opts); err != nil {
+		return err
 	}
+	paths := linuxpath.New(opts)
 	i.Devices = i.getDevices(opts)
+	i.setSlots(getSysfsSlots(paths), getSMBIOSSlots(paths))
+	hostCritical := getHostCriticalDevices(paths)
+	for _, dev := range i.Devices {
+		dev.HostCritical = hostCritical[dev.Address]
+	}
 	return nil
 }
 
@@ -125,6 +124,27 @@ func getDeviceDriver(paths *linuxpath.Paths, pciAddr *pciaddr.Address) string {
 	return filepath.Base(dest)
 }
 
+// readDeviceString returns the trimmed content of a sysfs attribute file, or
+// an empty string if the file cannot be read.
+func readDeviceString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// readDeviceInt returns the decimal integer value of a sysfs attribute file.
+// The boolean is false if the file is missing or its content is not a
+// decimal integer.
+func readDeviceInt(path string) (int, bool) {
+	val, err := strconv.Atoi(readDeviceString(path))
+	if err != nil {
+		return 0, false
+	}
+	return val, true
+}
+
 type deviceModaliasInfo struct {
 	vendorID     string
 	productID    string
@@ -385,6 +405,8 @@ func (info *Info) getDevices(opts *option.Options) []*Device {
 		opts.Warn("failed to read /sys/bus/pci/devices")
 		return nil
 	}
+	interrupts := getProcInterrupts(paths)
+	hasResetMethod := kernelHasResetMethod(paths)
 	for _, link := range links {
 		address := link.Name()
 		pciAddr := pciaddr.FromString(address)
@@ -414,12 +436,29 @@ func (info *Info) getDevices(opts *option.Options) []*Device {
 		device.Driver = getDeviceDriver(paths, pciAddr)
 		device.ParentAddress = getDeviceParentAddress(paths, pciAddr)
 		device.IOMMUGroup = getDeviceIommuGroup(paths, pciAddr)
+		device.SRIOV = getDeviceSRIOV(paths, pciAddr)
+		device.PhysicalFunction = getDevicePhysicalFunction(paths, pciAddr)
+		device.Link = getDevicePCIeLink(paths, pciAddr)
+		device.Resources = getDeviceResources(paths, pciAddr)
+		device.Capabilities = getDeviceCapabilities(paths, pciAddr)
+		device.Reset = getDeviceReset(paths, pciAddr, hasResetMethod, device.Capabilities)
+		device.AER = getDeviceAER(paths, pciAddr)
+		device.IRQ = getDeviceIRQ(paths, pciAddr)
+		device.Interrupts = getDeviceInterrupts(paths, pciAddr, device.IRQ, interrupts)
+		device.LocalCPUs = getDeviceLocalCPUs(paths, pciAddr)
+		if opts.PCIVPD {
+			device.VPD = getDeviceVPD(paths, pciAddr)
+		}
+		device.Power = getDevicePower(paths, pciAddr)
 		devs = append(devs, device)
 	}
 	return devs
 }
 
 // FindPCIAddress extract the pci address from a sysfs path without /sys
+//
+// Deprecated: use address.FromPath, which returns the PCI device the path
+// sits behind rather than the first one below the root bus.
 func FindPCIAddress(path string) string {
 	re := regexp.MustCompile(`\/?devices\/pci[\d:.]*\/(\d{4}:[a-f\d:\.]+)`)
 
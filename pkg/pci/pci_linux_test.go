@@ -306,3 +306,112 @@ func TestPCIModaliasWithUpperCaseClassID(t *testing.T) {
 		t.Fatalf("Failed to lookup class name")
 	}
 }
+
+// pciTestDevice describes a PCI device to be created in a fake sysfs tree by
+// pciTestSetupFakeSysfs.
+type pciTestDevice struct {
+	addr string
+	// address of the upstream bridge; empty for devices on a root bus
+	parentAddr string
+	// modalias of the device; a generic Intel NIC is used if empty
+	modalias string
+	// attribute files, keyed by the path relative to the device directory
+	attrs map[string]string
+	// symlinks, keyed by the path relative to the device directory, whose
+	// values are the verbatim link targets
+	links map[string]string
+}
+
+const pciTestDefaultModalias = "pci:v00008086d000010C9sv00008086sd0000A03Cbc02sc00i00\n"
+
+// pciTestSetupFakeSysfs creates a minimal sysfs tree containing the given
+// devices, which must be listed parents first, and returns the chroot
+// directory holding it.
+func pciTestSetupFakeSysfs(t *testing.T, devs []pciTestDevice) string {
+	root := t.TempDir()
+	busDir := filepath.Join(root, "sys", "bus", "pci", "devices")
+	if err := os.MkdirAll(busDir, 0755); err != nil {
+		t.Fatalf("could not create %q: %v", busDir, err)
+	}
+
+	devDirs := map[string]string{}
+	for _, dev := range devs {
+		var devDir string
+		if parentDir, ok := devDirs[dev.parentAddr]; ok {
+			devDir = filepath.Join(parentDir, dev.addr)
+		} else {
+			rootBus := "pci" + dev.addr[:7]
+			devDir = filepath.Join("devices", rootBus, dev.addr)
+		}
+		devDirs[dev.addr] = devDir
+
+		modalias := dev.modalias
+		if modalias == "" {
+			modalias = pciTestDefaultModalias
+		}
+		attrs := map[string]string{
+			"modalias": modalias,
+			"revision": "0x01\n",
+		}
+		for name, val := range dev.attrs {
+			attrs[name] = val
+		}
+		for name, val := range attrs {
+			pciTestWriteFile(t, root, filepath.Join("sys", devDir, name), val)
+		}
+		for name, target := range dev.links {
+			pciTestSymlink(t, root, filepath.Join("sys", devDir, name), target)
+		}
+		pciTestSymlink(
+			t, root,
+			filepath.Join("sys", "bus", "pci", "devices", dev.addr),
+			filepath.Join("..", "..", "..", devDir),
+		)
+	}
+	return root
+}
+
+// pciTestSetupFake returns a pci.Info built from a fake sysfs tree holding
+// the given devices.
+func pciTestSetupFake(t *testing.T, devs []pciTestDevice) *pci.Info {
+	if _, ok := os.LookupEnv("GHW_TESTING_SKIP_PCI"); ok {
+		t.Skip("Skipping PCI tests.")
+	}
+	return pciTestLoad(t, pciTestSetupFakeSysfs(t, devs))
+}
+
+// pciTestLoad returns a pci.Info built from the fake sysfs tree rooted at
+// the given chroot directory.
+func pciTestLoad(t *testing.T, root string) *pci.Info {
+	t.Setenv("PCIDB_PATH", testdata.PCIDBChroot())
+
+	info, err := pci.New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil err, but got %v", err)
+	}
+	return info
+}
+
+// pciTestWriteFile writes a file at the given path relative to the chroot
+// directory, creating the parent directories as needed.
+func pciTestWriteFile(t *testing.T, root, path, content string) {
+	fp := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+		t.Fatalf("could not create %q: %v", filepath.Dir(fp), err)
+	}
+	if err := os.WriteFile(fp, []byte(content), 0644); err != nil {
+		t.Fatalf("could not write %q: %v", fp, err)
+	}
+}
+
+// pciTestSymlink creates a symlink at the given path relative to the chroot
+// directory, creating the parent directories as needed.
+func pciTestSymlink(t *testing.T, root, path, target string) {
+	fp := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+		t.Fatalf("could not create %q: %v", filepath.Dir(fp), err)
+	}
+	if err := os.Symlink(target, fp); err != nil {
+		t.Fatalf("could not link %q: %v", fp, err)
+	}
+}
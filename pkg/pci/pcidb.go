@@ -0,0 +1,376 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jaypipes/pcidb"
+
+	"github.com/zededa/ghw/pkg/marshal"
+	"github.com/zededa/ghw/pkg/option"
+	"github.com/zededa/ghw/pkg/util"
+)
+
+// PCIDBSource tells where the PCI ID database used to resolve names came
+// from.
+type PCIDBSource string
+
+const (
+	// PCIDBSourceHost indicates the pci.ids file of the host was used
+	PCIDBSourceHost PCIDBSource = "host"
+	// PCIDBSourceEmbedded indicates the database embedded in ghw was used,
+	// see option.WithEmbeddedPCIDB
+	PCIDBSourceEmbedded PCIDBSource = "embedded"
+	// PCIDBSourceCustom indicates the caller supplied the database with
+	// option.WithPCIDB
+	PCIDBSourceCustom PCIDBSource = "custom"
+)
+
+// loadPCIDB sets the PCI database used to resolve names: the one supplied by
+// the caller if any, the host one otherwise, falling back to the embedded one
+// if allowed.
+func (i *Info) loadPCIDB(opts *option.Options) error {
+	if i.db != nil {
+		return nil
+	}
+	if opts.PCIDB != nil {
+		i.db = opts.PCIDB
+		i.DBSource = PCIDBSourceCustom
+		return nil
+	}
+	db, date, err := loadHostPCIDB()
+	if err == nil {
+		i.db = db
+		i.DBSource = PCIDBSourceHost
+		i.DBDate = date
+		return nil
+	}
+	if !opts.EmbeddedPCIDB {
+		return err
+	}
+	opts.Warn("%s; using the embedded PCI database\n", err)
+	db, date, err = loadEmbeddedPCIDB()
+	if err != nil {
+		return err
+	}
+	i.db = db
+	i.DBSource = PCIDBSourceEmbedded
+	i.DBDate = date
+	return nil
+}
+
+// openEmbeddedPCIDB opens the uncompressed pci.ids embedded in the binary,
+// nil unless the pciids package is linked in
+var openEmbeddedPCIDB func() (io.ReadCloser, error)
+
+// RegisterEmbeddedPCIDB sets the function opening the uncompressed pci.ids
+// embedded in the binary. It is called by the pciids package, which binaries
+// wanting the embedded database import for its side effect only, so that the
+// others do not carry it:
+//
+//	import _ "github.com/zededa/ghw/pkg/pci/pciids"
+func RegisterEmbeddedPCIDB(open func() (io.ReadCloser, error)) {
+	openEmbeddedPCIDB = open
+}
+
+// loadEmbeddedPCIDB returns the database embedded in ghw along with its date
+func loadEmbeddedPCIDB() (*pcidb.PCIDB, string, error) {
+	if openEmbeddedPCIDB == nil {
+		return nil, "", fmt.Errorf("no embedded PCI database: github.com/zededa/ghw/pkg/pci/pciids is not imported")
+	}
+	r, err := openEmbeddedPCIDB()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read the embedded pci.ids: %w", err)
+	}
+	defer r.Close()
+	db, date, err := pcidbFromReader(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse the embedded pci.ids: %w", err)
+	}
+	return db, date, nil
+}
+
+// loadHostPCIDB returns the pci.ids database of the host along with its
+// date, both read from the same file. pcidb does not tell which file it
+// loads, so ghw looks for it itself, at the paths pcidb would. Only when
+// there is none is pcidb asked for the database, which it may then fetch
+// from the network if PCIDB_ENABLE_NETWORK_FETCH allows it.
+func loadHostPCIDB() (*pcidb.PCIDB, string, error) {
+	for _, fp := range hostPCIDBPaths() {
+		f, err := os.Open(fp)
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+		var r io.Reader = f
+		if strings.HasSuffix(fp, ".gz") {
+			gz, err := gzip.NewReader(f)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to read %s: %w", fp, err)
+			}
+			defer gz.Close()
+			r = gz
+		}
+		db, date, err := pcidbFromReader(r)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse %s: %w", fp, err)
+		}
+		return db, date, nil
+	}
+	pcidbOpt := &pcidb.WithOption{}
+	if path := os.Getenv("PCIDB_PATH"); path != "" {
+		pcidbOpt = pcidb.WithPath(path)
+	}
+	db, err := pcidb.New(pcidbOpt)
+	return db, "", err
+}
+
+// pcidbFromReader parses a pci.ids database in memory, returning it along
+// with its date, e.g. "2022-01-22", taken from its header comments. pcidb only
+// loads databases from files, and the minimal images the embedded database is
+// meant for may have no writable directory to extract it to.
+//
+//	# Version: 2022.01.22
+//	# Date:    2022-01-22 03:15:02
+//	8086  Intel Corporation
+//		1533  I210 Gigabit Network Connection
+//			17aa 2233  ThinkCentre
+//	C 0c  Serial bus controller
+//		03  USB controller
+//			30  XHCI
+func pcidbFromReader(r io.Reader) (*pcidb.PCIDB, string, error) {
+	db := &pcidb.PCIDB{
+		Classes:  map[string]*pcidb.Class{},
+		Vendors:  map[string]*pcidb.Vendor{},
+		Products: map[string]*pcidb.Product{},
+	}
+	var (
+		class    *pcidb.Class
+		subclass *pcidb.Subclass
+		vendor   *pcidb.Vendor
+		product  *pcidb.Product
+	)
+	date, version := "", ""
+	inHeader := true
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			if inHeader {
+				switch key, value := pcidbHeaderField(line); key {
+				case "Date":
+					date = value
+				case "Version":
+					// versions are dates: 2022.01.22
+					version = strings.ReplaceAll(value, ".", "-")
+				}
+			}
+			continue
+		}
+		inHeader = false
+		entry := strings.TrimLeft(line, "\t")
+		depth := len(line) - len(entry)
+		id, name, found := strings.Cut(entry, "  ")
+		if !found {
+			continue
+		}
+		switch {
+		case depth == 0 && strings.HasPrefix(id, "C "):
+			class = &pcidb.Class{
+				ID:         strings.TrimPrefix(id, "C "),
+				Name:       name,
+				Subclasses: []*pcidb.Subclass{},
+			}
+			db.Classes[class.ID] = class
+			subclass, vendor, product = nil, nil, nil
+		case depth == 0:
+			vendor = &pcidb.Vendor{ID: id, Name: name, Products: []*pcidb.Product{}}
+			db.Vendors[vendor.ID] = vendor
+			class, subclass, product = nil, nil, nil
+		case depth == 1 && class != nil:
+			subclass = &pcidb.Subclass{
+				ID:                    id,
+				Name:                  name,
+				ProgrammingInterfaces: []*pcidb.ProgrammingInterface{},
+			}
+			class.Subclasses = append(class.Subclasses, subclass)
+		case depth == 1 && vendor != nil:
+			product = &pcidb.Product{
+				VendorID:   vendor.ID,
+				ID:         id,
+				Name:       name,
+				Subsystems: []*pcidb.Product{},
+			}
+			vendor.Products = append(vendor.Products, product)
+			db.Products[vendor.ID+product.ID] = product
+		case depth == 2 && subclass != nil:
+			subclass.ProgrammingInterfaces = append(
+				subclass.ProgrammingInterfaces,
+				&pcidb.ProgrammingInterface{ID: id, Name: name},
+			)
+		case depth == 2 && product != nil:
+			subvendorID, subsystemID, found := strings.Cut(id, " ")
+			if !found {
+				continue
+			}
+			product.Subsystems = append(product.Subsystems, &pcidb.Product{
+				VendorID: subvendorID,
+				ID:       subsystemID,
+				Name:     name,
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, "", err
+	}
+	if date == "" {
+		date = version
+	}
+	return db, date, nil
+}
+
+// hostPCIDBPaths returns the paths to look for the pci.ids file of the host
+// at, in order: the ones pcidb searches, honouring its environment variables.
+func hostPCIDBPaths() []string {
+	if path := os.Getenv("PCIDB_PATH"); path != "" {
+		return []string{path}
+	}
+	paths := []string{}
+	cachePath, ok := os.LookupEnv("PCIDB_CACHE_PATH")
+	if !ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			cachePath = filepath.Join(home, ".cache", "pci.ids")
+		}
+	}
+	if cachePath != "" {
+		paths = append(paths, cachePath)
+	}
+	if cacheOnly, err := strconv.ParseBool(os.Getenv("PCIDB_CACHE_ONLY")); err == nil && cacheOnly {
+		return paths
+	}
+	root := "/"
+	if chroot := os.Getenv("PCIDB_CHROOT"); chroot != "" {
+		root = chroot
+	}
+	for _, name := range []string{"pci.ids", "pci.ids.gz"} {
+		for _, dir := range []string{"hwdata", "misc"} {
+			paths = append(paths, filepath.Join(root, "usr", "share", dir, name))
+		}
+	}
+	return paths
+}
+
+// pcidbHeaderField returns the key and the first word of the value of a
+// header comment of a pci.ids database, e.g. "Date" and "2022-01-22" for
+// "# Date:    2022-01-22 03:15:02"
+func pcidbHeaderField(line string) (string, string) {
+	key, value, found := strings.Cut(strings.TrimSpace(strings.TrimPrefix(line, "#")), ":")
+	fields := strings.Fields(value)
+	if !found || len(fields) == 0 {
+		return "", ""
+	}
+	return strings.TrimSpace(key), fields[0]
+}
+
+// UnresolvedID is a PCI identifier the PCI ID database has no name for.
+type UnresolvedID struct {
+	// The PCI address of the device carrying the identifier
+	Address string `json:"address"`
+	// The kind of identifier: "vendor", "product", "subsystem", "class" or
+	// "subclass"
+	Kind string `json:"kind"`
+	// The identifier, e.g. "8086" for a vendor, "8086:1533" for a product,
+	// "17aa:2233" for a subsystem, "02" for a class or "0200" for a subclass
+	ID string `json:"id"`
+}
+
+func (u UnresolvedID) String() string {
+	return fmt.Sprintf("%s: unknown %s %s", u.Address, u.Kind, u.ID)
+}
+
+// UnresolvedIDs lists the identifiers of the host devices missing from the
+// PCI ID database, i.e. reported as "unknown". A long list usually means the
+// database is missing or outdated.
+func (info *Info) UnresolvedIDs() *UnresolvedIDs {
+	res := &UnresolvedIDs{
+		DBDate:   info.DBDate,
+		DBSource: info.DBSource,
+		IDs:      []UnresolvedID{},
+	}
+	add := func(dev *Device, kind, id string) {
+		res.IDs = append(res.IDs, UnresolvedID{Address: dev.Address, Kind: kind, ID: id})
+	}
+	for _, dev := range info.Devices {
+		if dev.Vendor != nil && dev.Vendor.Name == util.UNKNOWN {
+			add(dev, "vendor", dev.Vendor.ID)
+		}
+		if dev.Vendor != nil && dev.Product != nil && dev.Product.Name == util.UNKNOWN {
+			add(dev, "product", dev.Vendor.ID+":"+dev.Product.ID)
+		}
+		// a null subsystem vendor means the device has no subsystem IDs
+		if dev.Subsystem != nil && dev.Subsystem.Name == util.UNKNOWN &&
+			dev.Subsystem.VendorID != "" && dev.Subsystem.VendorID != "0000" {
+			add(dev, "subsystem", dev.Subsystem.VendorID+":"+dev.Subsystem.ID)
+		}
+		if dev.Class != nil && dev.Class.Name == util.UNKNOWN {
+			add(dev, "class", dev.Class.ID)
+		} else if dev.Class != nil && dev.Subclass != nil && dev.Subclass.Name == util.UNKNOWN {
+			add(dev, "subclass", dev.Class.ID+dev.Subclass.ID)
+		}
+	}
+	return res
+}
+
+// UnresolvedIDs is the list of the identifiers of the host devices missing
+// from the PCI ID database, along with the database used.
+type UnresolvedIDs struct {
+	DBDate   string         `json:"db_date"`
+	DBSource PCIDBSource    `json:"db_source"`
+	IDs      []UnresolvedID `json:"ids"`
+}
+
+func (u *UnresolvedIDs) String() string {
+	var sb strings.Builder
+	date := u.DBDate
+	if date == "" {
+		date = util.UNKNOWN
+	}
+	fmt.Fprintf(&sb, "PCI ID database (%s, %s): %d unresolved IDs", u.DBSource, date, len(u.IDs))
+	for _, id := range u.IDs {
+		fmt.Fprintf(&sb, "\n %s", id)
+	}
+	return sb.String()
+}
+
+// simple private struct used to encapsulate the unresolved IDs in a top-level
+// "pci_unresolved_ids" YAML/JSON map/object key
+type unresolvedIDsPrinter struct {
+	UnresolvedIDs *UnresolvedIDs `json:"pci_unresolved_ids"`
+}
+
+// YAMLString returns a string with the unresolved IDs formatted as YAML under
+// a top-level "pci_unresolved_ids:" key
+func (u *UnresolvedIDs) YAMLString() string {
+	return marshal.SafeYAML(unresolvedIDsPrinter{u})
+}
+
+// JSONString returns a string with the unresolved IDs formatted as JSON under
+// a top-level "pci_unresolved_ids:" key
+func (u *UnresolvedIDs) JSONString(indent bool) string {
+	return marshal.SafeJSON(unresolvedIDsPrinter{u}, indent)
+}
@@ -0,0 +1,154 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci_test
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jaypipes/pcidb"
+
+	"github.com/zededa/ghw/pkg/option"
+	"github.com/zededa/ghw/pkg/pci"
+	_ "github.com/zededa/ghw/pkg/pci/pciids"
+	"github.com/zededa/ghw/pkg/util"
+)
+
+func TestPCIEmbeddedDB(t *testing.T) {
+	root := pciTestSetupFakeSysfs(t, []pciTestDevice{
+		{
+			addr: "0000:00:19.0",
+		},
+	})
+	// no database anywhere
+	t.Setenv("PCIDB_PATH", "/nonexistent/pci.ids")
+
+	if _, err := pci.New(option.WithChroot(root), option.WithNullAlerter()); err == nil {
+		t.Fatalf("expected error without a PCI database")
+	}
+
+	info, err := pci.New(option.WithChroot(root), option.WithNullAlerter(), option.WithEmbeddedPCIDB())
+	if err != nil {
+		t.Fatalf("Expected nil err, but got %v", err)
+	}
+	if info.DBSource != pci.PCIDBSourceEmbedded || info.DBDate == "" {
+		t.Errorf("unexpected database %q %q", info.DBSource, info.DBDate)
+	}
+	dev := info.GetDevice("0000:00:19.0")
+	if dev == nil || dev.Vendor.Name == util.UNKNOWN {
+		t.Errorf("expected the vendor to be resolved, got %v", dev)
+	}
+	// vendor, product, subsystem, class and subclass
+	if ids := info.UnresolvedIDs(); len(ids.IDs) != 0 {
+		t.Errorf("expected all IDs to be resolved, got %v", ids)
+	}
+}
+
+func TestPCIHostDB(t *testing.T) {
+	info := pciTestSetupFake(t, []pciTestDevice{
+		{
+			addr: "0000:00:19.0",
+		},
+	})
+	if info.DBSource != pci.PCIDBSourceHost || info.DBDate != "2022-01-22" {
+		t.Errorf("unexpected database %q %q", info.DBSource, info.DBDate)
+	}
+}
+
+func TestPCIHostDBGzip(t *testing.T) {
+	root := pciTestSetupFakeSysfs(t, []pciTestDevice{
+		{
+			addr: "0000:00:19.0",
+		},
+	})
+	// the date and the names come from the same file
+	dbPath := filepath.Join(t.TempDir(), "pci.ids.gz")
+	f, err := os.Create(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	if _, err := gz.Write([]byte("#\n# Version: 2024.05.01\n# Date:    2024-05-01 03:15:01\n#\n\n8086  Intel Corporation\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	t.Setenv("PCIDB_PATH", dbPath)
+
+	info, err := pci.New(option.WithChroot(root), option.WithNullAlerter())
+	if err != nil {
+		t.Fatalf("Expected nil err, but got %v", err)
+	}
+	if info.DBSource != pci.PCIDBSourceHost || info.DBDate != "2024-05-01" {
+		t.Errorf("unexpected database %q %q", info.DBSource, info.DBDate)
+	}
+	if dev := info.GetDevice("0000:00:19.0"); dev == nil || dev.Vendor.Name != "Intel Corporation" {
+		t.Errorf("expected the vendor to be resolved, got %v", dev)
+	}
+}
+
+func TestPCICustomDB(t *testing.T) {
+	root := pciTestSetupFakeSysfs(t, []pciTestDevice{
+		{
+			addr: "0000:00:19.0",
+		},
+	})
+	db := &pcidb.PCIDB{
+		Classes:  map[string]*pcidb.Class{},
+		Vendors:  map[string]*pcidb.Vendor{},
+		Products: map[string]*pcidb.Product{},
+	}
+	info, err := pci.New(option.WithChroot(root), option.WithNullAlerter(), option.WithPCIDB(db))
+	if err != nil {
+		t.Fatalf("Expected nil err, but got %v", err)
+	}
+	if info.DBSource != pci.PCIDBSourceCustom {
+		t.Errorf("unexpected database source %q", info.DBSource)
+	}
+	// the custom database is empty: nothing resolves
+	if len(info.UnresolvedIDs().IDs) != 4 {
+		t.Errorf("expected 4 unresolved IDs, got %v", info.UnresolvedIDs())
+	}
+}
+
+func TestPCIUnresolvedIDs(t *testing.T) {
+	info := pciTestSetupFake(t, []pciTestDevice{
+		{
+			addr: "0000:00:19.0",
+		},
+		{
+			// made-up vendor, device with no subsystem
+			addr:     "0000:01:00.0",
+			modalias: "pci:v0000F00Dd0000BEEFsv00000000sd00000000bc02sc00i00\n",
+		},
+		{
+			// known vendor, made-up product and class
+			addr:     "0000:02:00.0",
+			modalias: "pci:v00008086d0000FFFEsv00008086sd0000FFFEbcFEsc00i00\n",
+		},
+	})
+
+	expected := []pci.UnresolvedID{
+		{Address: "0000:01:00.0", Kind: "vendor", ID: "f00d"},
+		{Address: "0000:01:00.0", Kind: "product", ID: "f00d:beef"},
+		{Address: "0000:02:00.0", Kind: "product", ID: "8086:fffe"},
+		{Address: "0000:02:00.0", Kind: "subsystem", ID: "8086:fffe"},
+		{Address: "0000:02:00.0", Kind: "class", ID: "fe"},
+	}
+	unresolved := info.UnresolvedIDs()
+	if !reflect.DeepEqual(unresolved.IDs, expected) {
+		t.Errorf("got %v expected %v", unresolved.IDs, expected)
+	}
+	if unresolved.DBDate != "2022-01-22" {
+		t.Errorf("unexpected database date %q", unresolved.DBDate)
+	}
+}
@@ -0,0 +1,46 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// Package pciids embeds a compressed copy of the pci.ids database from the PCI
+// ID Project (https://pci-ids.ucw.cz/), used to resolve PCI vendor, product
+// and class names on hosts shipping no database, such as minimal edge images.
+//
+// The embedded database adds about 300KB to the binaries importing this
+// package, which registers it with ghw's pci package. Import it for its side
+// effect, then request it with option.WithEmbeddedPCIDB:
+//
+//	import _ "github.com/zededa/ghw/pkg/pci/pciids"
+//
+// Unlike the rest of ghw, the embedded pci.ids is licensed under either the
+// GNU GPL version 2 or higher or the 3-clause BSD License, and redistributed
+// under the latter: binaries embedding it must reproduce the notice in the
+// LICENSE file of this package.
+package pciids
+
+import (
+	"bytes"
+	"compress/gzip"
+	_ "embed"
+	"io"
+
+	"github.com/zededa/ghw/pkg/pci"
+)
+
+// pci.ids.gz is refreshed from https://pci-ids.ucw.cz/ by running
+// `go generate ./pkg/pci/pciids` or `make pciids`
+//
+//go:generate ../../../hack/update-pciids.sh pci.ids.gz
+//go:embed pci.ids.gz
+var data []byte
+
+// Open returns a reader on the uncompressed embedded pci.ids database
+func Open() (io.ReadCloser, error) {
+	return gzip.NewReader(bytes.NewReader(data))
+}
+
+func init() {
+	pci.RegisterEmbeddedPCIDB(Open)
+}
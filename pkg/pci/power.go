@@ -0,0 +1,80 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"fmt"
+)
+
+// PCI device power states, as reported by the kernel in power_state
+const (
+	PowerStateD0     = "D0"
+	PowerStateD1     = "D1"
+	PowerStateD2     = "D2"
+	PowerStateD3Hot  = "D3hot"
+	PowerStateD3Cold = "D3cold"
+)
+
+// Runtime power management status and control values, as reported by the
+// kernel in power/runtime_status and power/control
+const (
+	RuntimeStatusActive      = "active"
+	RuntimeStatusSuspended   = "suspended"
+	RuntimeStatusSuspending  = "suspending"
+	RuntimeStatusResuming    = "resuming"
+	RuntimeStatusError       = "error"
+	RuntimeStatusUnsupported = "unsupported"
+
+	RuntimeControlAuto = "auto"
+	RuntimeControlOn   = "on"
+)
+
+// Power describes the power management state of a PCI device.
+type Power struct {
+	// The current power state, e.g. "D0", "D3hot" or "D3cold". Empty if
+	// the kernel does not report it.
+	State string `json:"state"`
+	// The runtime power management status, e.g. "active" or "suspended"
+	RuntimeStatus string `json:"runtime_status"`
+	// "auto" if the kernel may runtime-suspend the device when idle, "on" if
+	// the device is kept powered
+	RuntimeControl string `json:"runtime_control"`
+	// True if the device may be put in D3cold, i.e. completely powered off,
+	// when runtime-suspended
+	D3ColdAllowed bool `json:"d3cold_allowed"`
+	// True if the device is enabled, i.e. a driver or userspace enabled its
+	// memory and I/O decoding
+	Enabled bool `json:"enabled"`
+}
+
+func (p *Power) String() string {
+	return fmt.Sprintf(
+		"power state: %s runtime: %s (%s) enabled: %v",
+		p.State,
+		p.RuntimeStatus,
+		p.RuntimeControl,
+		p.Enabled,
+	)
+}
+
+// IsRuntimeSuspended returns true if the kernel runtime-suspended the device
+func (p *Power) IsRuntimeSuspended() bool {
+	return p.RuntimeStatus == RuntimeStatusSuspended
+}
+
+// IsRuntimePMEnabled returns true if the kernel is allowed to runtime-suspend
+// the device when idle
+func (p *Power) IsRuntimePMEnabled() bool {
+	return p.RuntimeControl == RuntimeControlAuto
+}
+
+// IsD3Cold returns true if the device is powered off. Its configuration space
+// is not accessible until it is woken up, which must happen before it can be
+// handed to a guest.
+func (p *Power) IsD3Cold() bool {
+	return p.State == PowerStateD3Cold
+}
@@ -0,0 +1,34 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"path/filepath"
+
+	"github.com/zededa/ghw/pkg/linuxpath"
+	pciaddr "github.com/zededa/ghw/pkg/pci/address"
+)
+
+// getDevicePower returns the power management state of the device at the
+// given address, or nil if the kernel reports none of it.
+func getDevicePower(paths *linuxpath.Paths, pciAddr *pciaddr.Address) *Power {
+	devPath := filepath.Join(paths.SysBusPciDevices, pciAddr.String())
+
+	power := &Power{
+		State:          readDeviceString(filepath.Join(devPath, "power_state")),
+		RuntimeStatus:  readDeviceString(filepath.Join(devPath, "power", "runtime_status")),
+		RuntimeControl: readDeviceString(filepath.Join(devPath, "power", "control")),
+	}
+	d3cold, hasD3cold := readDeviceInt(filepath.Join(devPath, "d3cold_allowed"))
+	power.D3ColdAllowed = d3cold != 0
+	// enable holds the number of times the device was enabled
+	enable, hasEnable := readDeviceInt(filepath.Join(devPath, "enable"))
+	power.Enabled = enable > 0
+	if power.State == "" && power.RuntimeStatus == "" && power.RuntimeControl == "" && !hasD3cold && !hasEnable {
+		return nil
+	}
+	return power
+}
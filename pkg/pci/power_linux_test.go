@@ -0,0 +1,91 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zededa/ghw/pkg/pci"
+)
+
+func TestPCIPower(t *testing.T) {
+	info := pciTestSetupFake(t, []pciTestDevice{
+		{
+			addr: "0000:00:14.0",
+			attrs: map[string]string{
+				"power_state":          "D0\n",
+				"power/runtime_status": "active\n",
+				"power/control":        "on\n",
+				"d3cold_allowed":       "1\n",
+				"enable":               "1\n",
+			},
+		},
+		{
+			addr: "0000:01:00.0",
+			attrs: map[string]string{
+				"power_state":          "D3cold\n",
+				"power/runtime_status": "suspended\n",
+				"power/control":        "auto\n",
+				"d3cold_allowed":       "1\n",
+				"enable":               "0\n",
+			},
+		},
+		{
+			addr: "0000:00:1f.0",
+		},
+	})
+
+	tCases := []struct {
+		addr      string
+		expected  *pci.Power
+		suspended bool
+		d3cold    bool
+	}{
+		{
+			addr: "0000:00:14.0",
+			expected: &pci.Power{
+				State:          pci.PowerStateD0,
+				RuntimeStatus:  pci.RuntimeStatusActive,
+				RuntimeControl: pci.RuntimeControlOn,
+				D3ColdAllowed:  true,
+				Enabled:        true,
+			},
+		},
+		{
+			addr: "0000:01:00.0",
+			expected: &pci.Power{
+				State:          pci.PowerStateD3Cold,
+				RuntimeStatus:  pci.RuntimeStatusSuspended,
+				RuntimeControl: pci.RuntimeControlAuto,
+				D3ColdAllowed:  true,
+			},
+			suspended: true,
+			d3cold:    true,
+		},
+		{
+			addr: "0000:00:1f.0",
+		},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.addr, func(t *testing.T) {
+			dev := info.GetDevice(tCase.addr)
+			if dev == nil {
+				t.Fatalf("expected device %q", tCase.addr)
+			}
+			if !reflect.DeepEqual(dev.Power, tCase.expected) {
+				t.Fatalf("got %+v expected %+v", dev.Power, tCase.expected)
+			}
+			if dev.Power == nil {
+				return
+			}
+			if dev.Power.IsRuntimeSuspended() != tCase.suspended || dev.Power.IsD3Cold() != tCase.d3cold {
+				t.Errorf("got suspended %v D3cold %v", dev.Power.IsRuntimeSuspended(), dev.Power.IsD3Cold())
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Reset methods, as named by the kernel in the reset_method attribute
+const (
+	ResetMethodDeviceSpecific = "device_specific"
+	ResetMethodACPI           = "acpi"
+	ResetMethodFLR            = "flr"
+	ResetMethodAFFLR          = "af_flr"
+	ResetMethodPM             = "pm"
+	ResetMethodBus            = "bus"
+	ResetMethodCXLBus         = "cxl_bus"
+)
+
+// ResetSource tells where the reset methods of a device were found.
+type ResetSource string
+
+const (
+	// ResetSourceSysfs indicates the methods were read from the reset_method
+	// attribute, available since Linux 5.15, which hides it for the devices
+	// it has no reset method for
+	ResetSourceSysfs ResetSource = "sysfs"
+	// ResetSourceConfigSpace indicates the methods were inferred from the
+	// capabilities of the device configuration space
+	ResetSourceConfigSpace ResetSource = "config-space"
+	// ResetSourceUnknown indicates neither source was available
+	ResetSourceUnknown ResetSource = "unknown"
+)
+
+// Reset describes how a PCI device can be reset, e.g. by the hypervisor when
+// a guest it is assigned to restarts.
+type Reset struct {
+	// The reset methods available for the device, in the order the kernel
+	// tries them
+	Methods []string    `json:"methods"`
+	Source  ResetSource `json:"source"`
+	// True if the device supports Function Level Reset, which the kernel
+	// may still not use, e.g. because of a quirk: see Methods
+	FLR bool `json:"flr"`
+	// True if the device can be reset without affecting other devices,
+	// hence can be safely reset between guest assignments. Devices that
+	// cannot be reset may hang when the guest they are assigned to restarts.
+	SafeReset bool `json:"safe_reset"`
+}
+
+func (r *Reset) String() string {
+	methods := "none"
+	if len(r.Methods) > 0 {
+		methods = strings.Join(r.Methods, ",")
+	}
+	safe := "unsafe"
+	if r.SafeReset {
+		safe = "safe"
+	}
+	return fmt.Sprintf("reset %s (%s, %s)", methods, safe, r.Source)
+}
+
+// HasMethod returns true if the given reset method is available for the
+// device.
+func (r *Reset) HasMethod(method string) bool {
+	for _, m := range r.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// newReset returns the reset description of a device from the content of its
+// reset_method attribute, empty if the kernel supports the attribute but has
+// no method for the device, falling back to its configuration space
+// capabilities if the kernel does not support the attribute.
+func newReset(resetMethod string, hasResetMethod bool, caps *Capabilities) *Reset {
+	reset := &Reset{Methods: []string{}}
+	switch {
+	case hasResetMethod:
+		reset.Source = ResetSourceSysfs
+		reset.Methods = append(reset.Methods, strings.Fields(resetMethod)...)
+	case caps != nil:
+		reset.Source = ResetSourceConfigSpace
+		if caps.Express != nil && caps.Express.FLR {
+			reset.Methods = append(reset.Methods, ResetMethodFLR)
+		}
+		if caps.PowerManagement != nil && !caps.PowerManagement.NoSoftReset {
+			reset.Methods = append(reset.Methods, ResetMethodPM)
+		}
+	default:
+		reset.Source = ResetSourceUnknown
+	}
+	reset.FLR = reset.HasMethod(ResetMethodFLR) || reset.HasMethod(ResetMethodAFFLR)
+	if !reset.FLR && caps != nil && caps.Express != nil {
+		reset.FLR = caps.Express.FLR
+	}
+	// The kernel only lists methods affecting the device alone: bus resets
+	// are only offered when the device is alone on its bus.
+	reset.SafeReset = len(reset.Methods) > 0
+	return reset
+}
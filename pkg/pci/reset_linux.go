@@ -0,0 +1,38 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/zededa/ghw/pkg/linuxpath"
+	pciaddr "github.com/zededa/ghw/pkg/pci/address"
+)
+
+// kernelHasResetMethod returns true if the running kernel exposes the
+// reset_method attribute, i.e. if at least one PCI device has it. Linux 5.15
+// and later hide it for the devices they cannot reset.
+func kernelHasResetMethod(paths *linuxpath.Paths) bool {
+	matches, err := filepath.Glob(filepath.Join(paths.SysBusPciDevices, "*", "reset_method"))
+	return err == nil && len(matches) > 0
+}
+
+// getDeviceReset returns the reset methods of the device at the given
+// address. The reset_method attribute is preferred: if the kernel supports it
+// but the device has none, the kernel cannot reset the device. On older
+// kernels, FLR and PM reset support are inferred from the already decoded
+// configuration space capabilities.
+func getDeviceReset(paths *linuxpath.Paths, pciAddr *pciaddr.Address, kernelHasResetMethod bool, caps *Capabilities) *Reset {
+	resetMethodPath := filepath.Join(paths.SysBusPciDevices, pciAddr.String(), "reset_method")
+
+	data, err := os.ReadFile(resetMethodPath)
+	if err != nil {
+		// no reset method at all if the kernel supports the attribute
+		return newReset("", kernelHasResetMethod, caps)
+	}
+	return newReset(string(data), true, caps)
+}
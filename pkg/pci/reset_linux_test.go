@@ -0,0 +1,123 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zededa/ghw/pkg/pci"
+)
+
+type pciTestResetCase struct {
+	addr    string
+	methods []string
+	source  pci.ResetSource
+	flr     bool
+	safe    bool
+}
+
+func TestPCIReset(t *testing.T) {
+	info := pciTestSetupFake(t, []pciTestDevice{
+		{
+			addr: "0000:01:00.0",
+			attrs: map[string]string{
+				"reset_method": "flr bus\n",
+			},
+		},
+		{
+			addr: "0000:02:00.0",
+			attrs: map[string]string{
+				"reset_method": "pm\n",
+			},
+		},
+		{
+			// the kernel supports reset_method but hides it: a quirk
+			// disables the FLR the PCIe capability advertises
+			addr: "0000:03:00.0",
+			attrs: map[string]string{
+				"config": string(pciTestConfigBlob()),
+			},
+		},
+	})
+	pciTestCheckReset(t, info, []pciTestResetCase{
+		{
+			addr:    "0000:01:00.0",
+			methods: []string{pci.ResetMethodFLR, pci.ResetMethodBus},
+			source:  pci.ResetSourceSysfs,
+			flr:     true,
+			safe:    true,
+		},
+		{
+			addr:    "0000:02:00.0",
+			methods: []string{pci.ResetMethodPM},
+			source:  pci.ResetSourceSysfs,
+			safe:    true,
+		},
+		{
+			addr:    "0000:03:00.0",
+			methods: []string{},
+			source:  pci.ResetSourceSysfs,
+			flr:     true,
+		},
+	})
+}
+
+func TestPCIResetOldKernel(t *testing.T) {
+	// no reset_method before Linux 5.15
+	info := pciTestSetupFake(t, []pciTestDevice{
+		{
+			// the PCIe capability advertises FLR, and PM reset is not
+			// usable (NoSoftRst+)
+			addr: "0000:03:00.0",
+			attrs: map[string]string{
+				"config": string(pciTestConfigBlob()),
+			},
+		},
+		{
+			addr: "0000:04:00.0",
+		},
+	})
+	pciTestCheckReset(t, info, []pciTestResetCase{
+		{
+			addr:    "0000:03:00.0",
+			methods: []string{pci.ResetMethodFLR},
+			source:  pci.ResetSourceConfigSpace,
+			flr:     true,
+			safe:    true,
+		},
+		{
+			addr:    "0000:04:00.0",
+			methods: []string{},
+			source:  pci.ResetSourceUnknown,
+		},
+	})
+}
+
+func pciTestCheckReset(t *testing.T, info *pci.Info, tCases []pciTestResetCase) {
+	for _, tCase := range tCases {
+		t.Run(tCase.addr, func(t *testing.T) {
+			dev := info.GetDevice(tCase.addr)
+			if dev == nil || dev.Reset == nil {
+				t.Fatalf("expected reset info for %q", tCase.addr)
+			}
+			reset := dev.Reset
+			if !reflect.DeepEqual(reset.Methods, tCase.methods) {
+				t.Errorf("got methods %v expected %v", reset.Methods, tCase.methods)
+			}
+			if reset.Source != tCase.source {
+				t.Errorf("got source %q expected %q", reset.Source, tCase.source)
+			}
+			if reset.FLR != tCase.flr {
+				t.Errorf("got FLR %v expected %v", reset.FLR, tCase.flr)
+			}
+			if reset.SafeReset != tCase.safe {
+				t.Errorf("got safe reset %v expected %v", reset.SafeReset, tCase.safe)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Resource flags, as defined in the kernel's include/linux/ioport.h
+const (
+	resourceFlagIO       = 0x00000100
+	resourceFlagMem      = 0x00000200
+	resourceFlagPrefetch = 0x00002000
+	resourceFlagMem64    = 0x00100000
+)
+
+// Resource describes an address region decoded by a PCI device: one of its
+// Base Address Registers (BARs), its expansion ROM, one of the BARs of its
+// SR-IOV virtual functions or, for bridges, one of its forwarding windows.
+type Resource struct {
+	// Position of the region in the sysfs resource file, i.e. the kernel
+	// resource number. Indexes 0 to 5 are the BARs and 6 is the expansion
+	// ROM. On kernels built with SR-IOV support, 7 to 12 are the VF BARs,
+	// each spanning the matching BAR of all the VFs, and the bridge windows
+	// start at 13; they start at 7 otherwise. Only bridges list their
+	// windows.
+	Index int `json:"index"`
+	// First address of the region
+	Start uint64 `json:"start"`
+	// Last address of the region
+	End uint64 `json:"end"`
+	// Size of the region in bytes
+	Size uint64 `json:"size"`
+	// Raw kernel resource flags
+	Flags uint64 `json:"flags"`
+	// True if the region lives in the IO port space
+	IO bool `json:"io"`
+	// True if the region lives in the memory space
+	Mem bool `json:"mem"`
+	// True if the region is a 64-bit memory BAR
+	Mem64 bool `json:"mem64"`
+	// True if the region is prefetchable memory
+	Prefetchable bool `json:"prefetchable"`
+}
+
+func (r Resource) String() string {
+	kind := "mem"
+	if r.IO {
+		kind = "io"
+	}
+	attrs := ""
+	if r.Mem64 {
+		attrs += " 64-bit"
+	}
+	if r.Prefetchable {
+		attrs += " prefetchable"
+	}
+	return fmt.Sprintf("[%d] %s %#x-%#x (size %#x)%s", r.Index, kind, r.Start, r.End, r.Size, attrs)
+}
+
+// Contains returns true if the given address falls within the region.
+func (r Resource) Contains(addr uint64) bool {
+	return addr >= r.Start && addr <= r.End
+}
+
+// ParseResources decodes the content of the sysfs resource file of a PCI
+// device. Each line of the file describes a region as "start end flags", all
+// hexadecimal. Unused regions are omitted from the returned slice.
+func ParseResources(data []byte) []Resource {
+	var res []Resource
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for index := 0; sc.Scan(); index++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		start, err0 := parseHex(fields[0])
+		end, err1 := parseHex(fields[1])
+		flags, err2 := parseHex(fields[2])
+		if err0 != nil || err1 != nil || err2 != nil {
+			continue
+		}
+		if (start == 0 && end == 0) || end < start {
+			continue
+		}
+		res = append(res, Resource{
+			Index:        index,
+			Start:        start,
+			End:          end,
+			Size:         end - start + 1,
+			Flags:        flags,
+			IO:           flags&resourceFlagIO != 0,
+			Mem:          flags&resourceFlagMem != 0,
+			Mem64:        flags&resourceFlagMem64 != 0,
+			Prefetchable: flags&resourceFlagPrefetch != 0,
+		})
+	}
+	return res
+}
+
+func parseHex(s string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(s), "0x"), 16, 64)
+}
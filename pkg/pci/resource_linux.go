@@ -0,0 +1,22 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/zededa/ghw/pkg/linuxpath"
+	pciaddr "github.com/zededa/ghw/pkg/pci/address"
+)
+
+func getDeviceResources(paths *linuxpath.Paths, pciAddr *pciaddr.Address) []Resource {
+	data, err := os.ReadFile(filepath.Join(paths.SysBusPciDevices, pciAddr.String(), "resource"))
+	if err != nil {
+		return nil
+	}
+	return ParseResources(data)
+}
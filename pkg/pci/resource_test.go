@@ -0,0 +1,69 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zededa/ghw/pkg/pci"
+)
+
+func TestParseResources(t *testing.T) {
+	data := `0x00000000fb000000 0x00000000fbffffff 0x0000000000040200
+0x000000e000000000 0x000000efffffffff 0x000000000014220c
+0x0000000000000000 0x0000000000000000 0x0000000000000000
+0x0000000000000000 0x0000000000000000 0x0000000000000000
+0x000000000000e000 0x000000000000e07f 0x0000000000040101
+0x0000000000000000 0x0000000000000000 0x0000000000000000
+0x00000000000c0000 0x00000000000dffff 0x0000000000000212
+`
+	expected := []pci.Resource{
+		{
+			Index: 0,
+			Start: 0xfb000000,
+			End:   0xfbffffff,
+			Size:  0x1000000,
+			Flags: 0x40200,
+			Mem:   true,
+		},
+		{
+			Index:        1,
+			Start:        0xe000000000,
+			End:          0xefffffffff,
+			Size:         0x1000000000,
+			Flags:        0x14220c,
+			Mem:          true,
+			Mem64:        true,
+			Prefetchable: true,
+		},
+		{
+			Index: 4,
+			Start: 0xe000,
+			End:   0xe07f,
+			Size:  0x80,
+			Flags: 0x40101,
+			IO:    true,
+		},
+		{
+			Index: 6,
+			Start: 0xc0000,
+			End:   0xdffff,
+			Size:  0x20000,
+			Flags: 0x212,
+			Mem:   true,
+		},
+	}
+
+	res := pci.ParseResources([]byte(data))
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("got resources %+v expected %+v", res, expected)
+	}
+	if !res[2].Contains(0xe040) || res[2].Contains(0xe080) {
+		t.Errorf("unexpected IO range containment for %v", res[2])
+	}
+}
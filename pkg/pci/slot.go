@@ -0,0 +1,286 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	pciaddr "github.com/zededa/ghw/pkg/pci/address"
+)
+
+// SlotSource tells where the information about a physical slot was found.
+type SlotSource string
+
+const (
+	// SlotSourceSysfs indicates the slot was registered by a hotplug or ACPI
+	// slot driver under /sys/bus/pci/slots
+	SlotSourceSysfs SlotSource = "sysfs"
+	// SlotSourceSMBIOS indicates the slot was described by an SMBIOS System
+	// Slot (type 9) record
+	SlotSourceSMBIOS SlotSource = "smbios"
+)
+
+// Slot describes a physical PCI slot of the host system.
+type Slot struct {
+	// The slot designation, e.g. "SLOT 3" or "PCIe Slot 1", as found in
+	// SMBIOS if available, which usually matches the motherboard
+	// silkscreen. Otherwise the name of the slot under /sys/bus/pci/slots.
+	Name string `json:"name"`
+	// The physical slot number, or -1 if unknown
+	Number int `json:"number"`
+	// The domain, bus and device number of the devices in the slot, e.g.
+	// "0000:3b:00", or only the domain and bus, e.g. "0000:3b", for a slot
+	// holding the whole bus, as the kernel reports PCIe hotplug slots. Empty
+	// if unknown, e.g. for an empty slot described only by SMBIOS.
+	Address string `json:"address,omitempty"`
+	// True if devices can be added to or removed from the slot at runtime
+	Hotplug bool `json:"hotplug"`
+	// The number of lanes of the slot as wired on the motherboard, or 0 if
+	// unknown
+	MaxBusWidth int `json:"max_bus_width"`
+	// The number of lanes negotiated by the device in the slot, or 0 if the
+	// slot is empty or the width is unknown
+	CurrentBusWidth int `json:"current_bus_width"`
+	// Maximum and current bus speed, as reported by the kernel, e.g.
+	// "8.0 GT/s PCIe"
+	MaxBusSpeed     string       `json:"max_bus_speed,omitempty"`
+	CurrentBusSpeed string       `json:"current_bus_speed,omitempty"`
+	Sources         []SlotSource `json:"sources"`
+	// SMBIOS address of the slot, which may be the one of the port the slot
+	// is attached to rather than the one of the device in the slot
+	smbiosAddress string
+}
+
+func (s *Slot) String() string {
+	str := fmt.Sprintf("slot %q", s.Name)
+	if s.Address != "" {
+		str += " @ " + s.Address
+	}
+	if s.MaxBusWidth > 0 {
+		str += fmt.Sprintf(" x%d", s.MaxBusWidth)
+	}
+	if s.Hotplug {
+		str += " hotplug"
+	}
+	return str
+}
+
+// slotAddress returns the domain, bus and device number of a PCI address,
+// i.e. the part shared by all the functions of a device.
+func slotAddress(address string) string {
+	addr := pciaddr.FromString(address)
+	if addr == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s:%s:%s", addr.Domain, addr.Bus, addr.Device)
+}
+
+// slotBusAddress returns the domain and bus number of a PCI address, i.e. the
+// address of a slot holding the whole bus.
+func slotBusAddress(address string) string {
+	addr := pciaddr.FromString(address)
+	if addr == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s:%s", addr.Domain, addr.Bus)
+}
+
+// slotFirstFunction returns the address of the first function of the first
+// device in the slot with the given address
+func slotFirstFunction(slotAddress string) string {
+	if strings.Count(slotAddress, ":") == 1 {
+		// the slot holds the whole bus
+		return slotAddress + ":00.0"
+	}
+	return slotAddress + ".0"
+}
+
+// slotNumber returns the slot number at the start of a sysfs slot name, e.g.
+// 3 for "3" or "3-1", or -1 if the name is not numeric, e.g. for ACPI names.
+func slotNumber(name string) int {
+	num, _, _ := strings.Cut(name, "-")
+	n, err := strconv.Atoi(num)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// SMBIOS System Slot (type 9) record layout
+const (
+	smbiosTypeSystemSlot = 9
+
+	smbiosSlotDesignation   = 0x04
+	smbiosSlotDataBusWidth  = 0x06
+	smbiosSlotID            = 0x09
+	smbiosSlotChar2         = 0x0c
+	smbiosSlotSegment       = 0x0d
+	smbiosSlotBus           = 0x0f
+	smbiosSlotDevFn         = 0x10
+	smbiosSlotMinLen        = 0x0d
+	smbiosSlotMinLenAddress = 0x11
+
+	smbiosSlotChar2Hotplug = 1 << 1
+)
+
+// smbiosSlotWidths maps the SMBIOS Slot Data Bus Width values to numbers of
+// lanes; parallel PCI widths are not lanes and are omitted
+var smbiosSlotWidths = map[byte]int{
+	0x08: 1,
+	0x09: 2,
+	0x0a: 4,
+	0x0b: 8,
+	0x0c: 12,
+	0x0d: 16,
+	0x0e: 32,
+}
+
+// parseSMBIOSSlot decodes a raw SMBIOS System Slot record, as found in
+// /sys/firmware/dmi/entries/9-*/raw. Returns nil if the record is not a
+// valid type 9 record.
+func parseSMBIOSSlot(raw []byte) *Slot {
+	if len(raw) < smbiosSlotMinLen || raw[0] != smbiosTypeSystemSlot {
+		return nil
+	}
+	length := int(raw[1])
+	if length < smbiosSlotMinLen || length > len(raw) {
+		return nil
+	}
+	slot := &Slot{
+		Name:        smbiosString(raw[length:], raw[smbiosSlotDesignation]),
+		Number:      int(binary.LittleEndian.Uint16(raw[smbiosSlotID:])),
+		MaxBusWidth: smbiosSlotWidths[raw[smbiosSlotDataBusWidth]],
+		Hotplug:     raw[smbiosSlotChar2]&smbiosSlotChar2Hotplug != 0,
+		Sources:     []SlotSource{SlotSourceSMBIOS},
+	}
+	if length >= smbiosSlotMinLenAddress {
+		segment := binary.LittleEndian.Uint16(raw[smbiosSlotSegment:])
+		bus := raw[smbiosSlotBus]
+		devfn := raw[smbiosSlotDevFn]
+		// 0xff bus and devfn mean the address is not provided
+		if bus != 0xff || devfn != 0xff {
+			slot.smbiosAddress = fmt.Sprintf(
+				"%04x:%02x:%02x.%x", segment, bus, devfn>>3, devfn&0x7,
+			)
+		}
+	}
+	return slot
+}
+
+// smbiosString returns the string with the given 1-based index from the
+// string set following the formatted area of an SMBIOS record.
+func smbiosString(strs []byte, index byte) string {
+	if index == 0 {
+		return ""
+	}
+	for idx := byte(1); len(strs) > 0 && strs[0] != 0; idx++ {
+		end := 0
+		for end < len(strs) && strs[end] != 0 {
+			end++
+		}
+		if idx == index {
+			return strings.TrimSpace(string(strs[:end]))
+		}
+		if end == len(strs) {
+			break
+		}
+		strs = strs[end+1:]
+	}
+	return ""
+}
+
+// setSlots merges the slots found in sysfs and SMBIOS and attaches them to
+// the devices they hold.
+func (info *Info) setSlots(sysfsSlots, smbiosSlots []*Slot) {
+	slots := append([]*Slot{}, sysfsSlots...)
+	byAddress := map[string]*Slot{}
+	for _, slot := range sysfsSlots {
+		if slot.Address != "" {
+			byAddress[slot.Address] = slot
+		}
+	}
+	// the slot holding the device with the given address, if any
+	slotOf := func(address string) *Slot {
+		if slot, ok := byAddress[slotAddress(address)]; ok {
+			return slot
+		}
+		return byAddress[slotBusAddress(address)]
+	}
+	for _, slot := range smbiosSlots {
+		slot.Address = info.smbiosSlotAddress(slot.smbiosAddress)
+		existing := slotOf(slotFirstFunction(slot.Address))
+		if existing == nil || slot.Address == "" {
+			if slot.Address != "" {
+				byAddress[slot.Address] = slot
+			}
+			slots = append(slots, slot)
+			continue
+		}
+		// the SMBIOS designation is the one printed on the motherboard
+		if slot.Name != "" {
+			existing.Name = slot.Name
+		}
+		if existing.Number < 0 {
+			existing.Number = slot.Number
+		}
+		existing.Hotplug = existing.Hotplug || slot.Hotplug
+		if slot.MaxBusWidth > 0 {
+			existing.MaxBusWidth = slot.MaxBusWidth
+		}
+		existing.Sources = append(existing.Sources, SlotSourceSMBIOS)
+	}
+
+	for _, dev := range info.Devices {
+		dev.Slot = slotOf(dev.Address)
+	}
+	// devices behind a switch or bridge on an add-in card are in the same
+	// slot as the card
+	for _, dev := range info.Devices {
+		if dev.Slot != nil {
+			continue
+		}
+		for _, ancestor := range info.Ancestors(dev.Address) {
+			if ancestor.Slot != nil {
+				dev.Slot = ancestor.Slot
+				break
+			}
+		}
+	}
+
+	for _, slot := range slots {
+		dev := info.lookupDevice(slotFirstFunction(slot.Address))
+		if dev == nil || dev.Link == nil {
+			continue
+		}
+		slot.CurrentBusWidth = dev.Link.CurrentWidth
+	}
+	sort.SliceStable(slots, func(i, j int) bool {
+		return slots[i].Address < slots[j].Address
+	})
+	info.Slots = slots
+}
+
+// smbiosSlotAddress returns the address of the devices in a slot from the
+// address found in SMBIOS. Firmwares report either the address of the device
+// in the slot, or the one of the port the slot is wired to.
+func (info *Info) smbiosSlotAddress(address string) string {
+	dev := info.lookupDevice(address)
+	if dev == nil || !dev.IsBridge() {
+		return slotAddress(address)
+	}
+	for _, child := range info.Devices {
+		if child.ParentAddress == dev.Address && child.Address != dev.Address {
+			return slotAddress(child.Address)
+		}
+	}
+	// empty slot: the secondary bus number of the port is unknown
+	return ""
+}
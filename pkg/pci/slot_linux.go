@@ -0,0 +1,61 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/zededa/ghw/pkg/linuxpath"
+)
+
+// getSysfsSlots returns the slots registered under /sys/bus/pci/slots, either
+// by a hotplug driver such as pciehp or by the ACPI pci_slot driver.
+func getSysfsSlots(paths *linuxpath.Paths) []*Slot {
+	entries, err := os.ReadDir(paths.SysBusPciSlots)
+	if err != nil {
+		return nil
+	}
+	slots := make([]*Slot, 0, len(entries))
+	for _, entry := range entries {
+		slotPath := filepath.Join(paths.SysBusPciSlots, entry.Name())
+		slot := &Slot{
+			Name:            entry.Name(),
+			Number:          slotNumber(entry.Name()),
+			Address:         readDeviceString(filepath.Join(slotPath, "address")),
+			MaxBusSpeed:     readDeviceString(filepath.Join(slotPath, "max_bus_speed")),
+			CurrentBusSpeed: readDeviceString(filepath.Join(slotPath, "cur_bus_speed")),
+			Sources:         []SlotSource{SlotSourceSysfs},
+		}
+		// only hotplug drivers expose the power control of the slot
+		if _, err := os.Stat(filepath.Join(slotPath, "power")); err == nil {
+			slot.Hotplug = true
+		}
+		slots = append(slots, slot)
+	}
+	return slots
+}
+
+// getSMBIOSSlots returns the slots described by the SMBIOS System Slot
+// records exported under /sys/firmware/dmi/entries. Reading them usually
+// requires root privileges.
+func getSMBIOSSlots(paths *linuxpath.Paths) []*Slot {
+	raws, err := filepath.Glob(filepath.Join(paths.SysFirmwareDMIEntries, "9-*", "raw"))
+	if err != nil {
+		return nil
+	}
+	slots := make([]*Slot, 0, len(raws))
+	for _, raw := range raws {
+		data, err := os.ReadFile(raw)
+		if err != nil {
+			continue
+		}
+		if slot := parseSMBIOSSlot(data); slot != nil {
+			slots = append(slots, slot)
+		}
+	}
+	return slots
+}
@@ -0,0 +1,156 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zededa/ghw/pkg/pci"
+)
+
+// slotTestSMBIOSRecord returns a raw SMBIOS System Slot record, in the
+// format found in /sys/firmware/dmi/entries/9-*/raw.
+func slotTestSMBIOSRecord(designation string, id uint16, width, char2, bus, devfn byte) string {
+	raw := []byte{
+		0x09, 0x11, 0x00, 0x09, // type, length, handle
+		0x01,  // designation: string #1
+		0xb6,  // type: PCI Express Gen 3
+		width, // data bus width
+		0x04,  // current usage: in use
+		0x04,  // length: long
+		byte(id), byte(id >> 8),
+		0x0c,       // characteristics 1
+		char2,      // characteristics 2
+		0x00, 0x00, // segment
+		bus,
+		devfn,
+	}
+	raw = append(raw, designation...)
+	raw = append(raw, 0, 0)
+	return string(raw)
+}
+
+func TestPCISlots(t *testing.T) {
+	const bridgeModalias = "pci:v00008086d0000460Dsv00008086sd00007270bc06sc04i00\n"
+	root := pciTestSetupFakeSysfs(t, []pciTestDevice{
+		{
+			addr:     "0000:00:01.0",
+			modalias: bridgeModalias,
+		},
+		{
+			addr:       "0000:01:00.0",
+			parentAddr: "0000:00:01.0",
+			attrs: map[string]string{
+				"current_link_speed": "8.0 GT/s PCIe\n",
+				"current_link_width": "8\n",
+				"max_link_speed":     "8.0 GT/s PCIe\n",
+				"max_link_width":     "8\n",
+			},
+		},
+		{
+			addr:       "0000:01:00.1",
+			parentAddr: "0000:00:01.0",
+		},
+		{
+			addr:     "0000:00:02.0",
+			modalias: bridgeModalias,
+		},
+		{
+			addr:       "0000:02:00.0",
+			parentAddr: "0000:00:02.0",
+			modalias:   bridgeModalias,
+		},
+		{
+			addr:       "0000:03:00.0",
+			parentAddr: "0000:02:00.0",
+		},
+		{
+			addr: "0000:00:1f.6",
+		},
+	})
+	// hotplug slot, also described by SMBIOS by the address of its port.
+	// pciehp slots hold the whole bus, their address has no device number.
+	pciTestWriteFile(t, root, "sys/bus/pci/slots/3/address", "0000:01\n")
+	pciTestWriteFile(t, root, "sys/bus/pci/slots/3/power", "1\n")
+	pciTestWriteFile(t, root, "sys/bus/pci/slots/3/max_bus_speed", "8.0 GT/s PCIe\n")
+	pciTestWriteFile(t, root, "sys/bus/pci/slots/3/cur_bus_speed", "8.0 GT/s PCIe\n")
+	pciTestWriteFile(t, root, "sys/firmware/dmi/entries/9-0/raw",
+		slotTestSMBIOSRecord("SLOT 3", 3, 0x0d, 0x02, 0x00, 0x08))
+	// slot only known to the ACPI slot driver, holding a card with a switch
+	pciTestWriteFile(t, root, "sys/bus/pci/slots/5/address", "0000:02:00\n")
+	// empty slot without address
+	pciTestWriteFile(t, root, "sys/firmware/dmi/entries/9-1/raw",
+		slotTestSMBIOSRecord("PCIe Slot 7", 7, 0x0a, 0x00, 0xff, 0xff))
+
+	info := pciTestLoad(t, root)
+
+	expected := map[string]pci.Slot{
+		"SLOT 3": {
+			Name:            "SLOT 3",
+			Number:          3,
+			Address:         "0000:01",
+			Hotplug:         true,
+			MaxBusWidth:     16,
+			CurrentBusWidth: 8,
+			MaxBusSpeed:     "8.0 GT/s PCIe",
+			CurrentBusSpeed: "8.0 GT/s PCIe",
+			Sources:         []pci.SlotSource{pci.SlotSourceSysfs, pci.SlotSourceSMBIOS},
+		},
+		"5": {
+			Name:    "5",
+			Number:  5,
+			Address: "0000:02:00",
+			Sources: []pci.SlotSource{pci.SlotSourceSysfs},
+		},
+		"PCIe Slot 7": {
+			Name:        "PCIe Slot 7",
+			Number:      7,
+			MaxBusWidth: 4,
+			Sources:     []pci.SlotSource{pci.SlotSourceSMBIOS},
+		},
+	}
+	if len(info.Slots) != len(expected) {
+		t.Fatalf("expected %d slots, got %v", len(expected), info.Slots)
+	}
+	for _, slot := range info.Slots {
+		exp, ok := expected[slot.Name]
+		if !ok {
+			t.Fatalf("unexpected slot %v", slot)
+		}
+		if !reflect.DeepEqual(*slot, exp) {
+			t.Errorf("slot %q: got %+v expected %+v", slot.Name, *slot, exp)
+		}
+	}
+
+	tCases := []struct {
+		addr string
+		slot string
+	}{
+		{addr: "0000:01:00.0", slot: "SLOT 3"},
+		{addr: "0000:01:00.1", slot: "SLOT 3"},
+		{addr: "0000:02:00.0", slot: "5"},
+		{addr: "0000:03:00.0", slot: "5"},
+		{addr: "0000:00:01.0"},
+		{addr: "0000:00:1f.6"},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.addr, func(t *testing.T) {
+			dev := info.GetDevice(tCase.addr)
+			if dev == nil {
+				t.Fatalf("expected device %q", tCase.addr)
+			}
+			name := ""
+			if dev.Slot != nil {
+				name = dev.Slot.Name
+			}
+			if name != tCase.slot {
+				t.Errorf("got slot %q expected %q", name, tCase.slot)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"fmt"
+)
+
+// SRIOV describes the Single Root I/O Virtualization configuration of a PCI
+// physical function (PF). It is only present for devices which expose the
+// SR-IOV capability.
+type SRIOV struct {
+	// Maximum number of virtual functions (VFs) the device supports
+	TotalVFs int `json:"total_vfs"`
+	// Number of virtual functions currently enabled
+	NumVFs int `json:"num_vfs"`
+	// PCI device (product) ID the virtual functions report
+	VFDeviceID string `json:"vf_device_id"`
+	// Routing ID offset of the first virtual function relative to the PF
+	VFOffset int `json:"vf_offset"`
+	// Routing ID distance between two consecutive virtual functions
+	VFStride int `json:"vf_stride"`
+	// PCI addresses of the enabled virtual functions, ordered by VF index
+	VirtualFunctions []string `json:"virtual_functions"`
+}
+
+func (s *SRIOV) String() string {
+	return fmt.Sprintf(
+		"SR-IOV (%d/%d VFs) vf_device: '%s' offset: %d stride: %d",
+		s.NumVFs,
+		s.TotalVFs,
+		s.VFDeviceID,
+		s.VFOffset,
+		s.VFStride,
+	)
+}
+
+// IsPhysicalFunction returns true if the device is a SR-IOV capable physical
+// function.
+func (d *Device) IsPhysicalFunction() bool {
+	return d.SRIOV != nil
+}
+
+// IsVirtualFunction returns true if the device is a SR-IOV virtual function
+// spawned by a physical function.
+func (d *Device) IsVirtualFunction() bool {
+	return d.PhysicalFunction != ""
+}
@@ -0,0 +1,99 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zededa/ghw/pkg/linuxpath"
+	pciaddr "github.com/zededa/ghw/pkg/pci/address"
+)
+
+const (
+	// prefix of the symlinks a PF has to each of its VFs, e.g. virtfn0
+	virtfnPrefix = "virtfn"
+)
+
+// getDeviceSRIOV returns the SR-IOV information of the physical function at
+// the given address, or nil if the device is not SR-IOV capable.
+func getDeviceSRIOV(paths *linuxpath.Paths, pciAddr *pciaddr.Address) *SRIOV {
+	devPath := filepath.Join(paths.SysBusPciDevices, pciAddr.String())
+
+	// sriov_totalvfs is only exposed by devices having the SR-IOV capability
+	totalVFs, ok := readDeviceInt(filepath.Join(devPath, "sriov_totalvfs"))
+	if !ok {
+		return nil
+	}
+	sriov := &SRIOV{
+		TotalVFs:         totalVFs,
+		VirtualFunctions: getDeviceVirtualFunctions(devPath),
+	}
+	sriov.NumVFs, _ = readDeviceInt(filepath.Join(devPath, "sriov_numvfs"))
+	sriov.VFOffset, _ = readDeviceInt(filepath.Join(devPath, "sriov_offset"))
+	sriov.VFStride, _ = readDeviceInt(filepath.Join(devPath, "sriov_stride"))
+	sriov.VFDeviceID = strings.ToLower(
+		strings.TrimPrefix(readDeviceString(filepath.Join(devPath, "sriov_vf_device")), "0x"),
+	)
+	return sriov
+}
+
+// getDeviceVirtualFunctions follows the virtfnN symlinks of a physical
+// function and returns the addresses of its VFs, ordered by VF index.
+func getDeviceVirtualFunctions(devPath string) []string {
+	links, err := filepath.Glob(filepath.Join(devPath, virtfnPrefix+"*"))
+	if err != nil {
+		return nil
+	}
+	type vf struct {
+		index   int
+		address string
+	}
+	vfs := make([]vf, 0, len(links))
+	for _, link := range links {
+		index, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(link), virtfnPrefix))
+		if err != nil {
+			continue
+		}
+		dest, err := os.Readlink(link)
+		if err != nil {
+			continue
+		}
+		address := filepath.Base(dest)
+		if pciaddr.FromString(address) == nil {
+			continue
+		}
+		vfs = append(vfs, vf{index: index, address: address})
+	}
+	sort.Slice(vfs, func(i, j int) bool {
+		return vfs[i].index < vfs[j].index
+	})
+	addrs := make([]string, 0, len(vfs))
+	for _, v := range vfs {
+		addrs = append(addrs, v.address)
+	}
+	return addrs
+}
+
+// getDevicePhysicalFunction returns the address of the physical function
+// which spawned the virtual function at the given address, or an empty string
+// if the device is not a VF.
+func getDevicePhysicalFunction(paths *linuxpath.Paths, pciAddr *pciaddr.Address) string {
+	physfnPath := filepath.Join(paths.SysBusPciDevices, pciAddr.String(), "physfn")
+
+	dest, err := os.Readlink(physfnPath)
+	if err != nil {
+		return ""
+	}
+	address := filepath.Base(dest)
+	if pciaddr.FromString(address) == nil {
+		return ""
+	}
+	return address
+}
@@ -0,0 +1,88 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci_test
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPCISRIOV(t *testing.T) {
+	info := pciTestSetupFake(t, []pciTestDevice{
+		{
+			addr: "0000:00:01.0",
+		},
+		{
+			addr:       "0000:01:00.0",
+			parentAddr: "0000:00:01.0",
+			attrs: map[string]string{
+				"sriov_totalvfs":  "7\n",
+				"sriov_numvfs":    "2\n",
+				"sriov_offset":    "128\n",
+				"sriov_stride":    "2\n",
+				"sriov_vf_device": "10ca\n",
+			},
+			links: map[string]string{
+				"virtfn1": "../0000:01:10.2",
+				"virtfn0": "../0000:01:10.0",
+			},
+		},
+		{
+			addr:       "0000:01:10.0",
+			parentAddr: "0000:00:01.0",
+			links: map[string]string{
+				"physfn": "../0000:01:00.0",
+			},
+		},
+		{
+			addr:       "0000:01:10.2",
+			parentAddr: "0000:00:01.0",
+			links: map[string]string{
+				"physfn": "../0000:01:00.0",
+			},
+		},
+	})
+
+	pf := info.GetDevice("0000:01:00.0")
+	if pf == nil {
+		t.Fatalf("got nil device for PF")
+	}
+	if !pf.IsPhysicalFunction() || pf.IsVirtualFunction() {
+		t.Fatalf("expected %q to be a physical function", pf.Address)
+	}
+	if pf.SRIOV.TotalVFs != 7 || pf.SRIOV.NumVFs != 2 {
+		t.Errorf("got VFs %d/%d expected 2/7", pf.SRIOV.NumVFs, pf.SRIOV.TotalVFs)
+	}
+	if pf.SRIOV.VFOffset != 128 || pf.SRIOV.VFStride != 2 {
+		t.Errorf("got offset %d stride %d expected 128 and 2", pf.SRIOV.VFOffset, pf.SRIOV.VFStride)
+	}
+	if pf.SRIOV.VFDeviceID != "10ca" {
+		t.Errorf("got VF device ID %q expected \"10ca\"", pf.SRIOV.VFDeviceID)
+	}
+	expectedVFs := []string{"0000:01:10.0", "0000:01:10.2"}
+	if !reflect.DeepEqual(pf.SRIOV.VirtualFunctions, expectedVFs) {
+		t.Errorf("got VFs %v expected %v", pf.SRIOV.VirtualFunctions, expectedVFs)
+	}
+
+	for _, addr := range expectedVFs {
+		vf := info.GetDevice(addr)
+		if vf == nil {
+			t.Fatalf("got nil device for VF %q", addr)
+		}
+		if !vf.IsVirtualFunction() || vf.IsPhysicalFunction() {
+			t.Fatalf("expected %q to be a virtual function", addr)
+		}
+		if vf.PhysicalFunction != pf.Address {
+			t.Errorf("got PF %q for VF %q expected %q", vf.PhysicalFunction, addr, pf.Address)
+		}
+	}
+
+	bridge := info.GetDevice("0000:00:01.0")
+	if bridge.SRIOV != nil || bridge.PhysicalFunction != "" {
+		t.Errorf("expected no SR-IOV information for %q", bridge.Address)
+	}
+}
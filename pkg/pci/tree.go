@@ -0,0 +1,220 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zededa/ghw/pkg/marshal"
+	pciaddr "github.com/zededa/ghw/pkg/pci/address"
+	"github.com/zededa/ghw/pkg/util"
+)
+
+// TreeNode is a PCI device in the bridge hierarchy, along with the devices
+// directly attached below it if it is a bridge.
+type TreeNode struct {
+	Device   *Device     `json:"device"`
+	Children []*TreeNode `json:"children,omitempty"`
+}
+
+// TreeRoot is a root bus of the PCI hierarchy, as exposed by a host bridge.
+type TreeRoot struct {
+	Domain string `json:"domain"`
+	Bus    string `json:"bus"`
+	// The devices attached directly to the root bus
+	Children []*TreeNode `json:"children"`
+}
+
+// Tree is the PCI bridge hierarchy of the host system.
+type Tree struct {
+	Roots []*TreeRoot `json:"roots"`
+}
+
+// WalkFunc is called by Tree.Walk for every device of the tree. depth is 0
+// for the devices attached to a root bus. Returning false skips the subtree
+// of the device.
+type WalkFunc func(node *TreeNode, depth int) bool
+
+// Tree returns the PCI devices of the host system arranged by the bridges
+// they are attached to.
+func (info *Info) Tree() *Tree {
+	nodes := make(map[string]*TreeNode, len(info.Devices))
+	for _, dev := range info.Devices {
+		nodes[dev.Address] = &TreeNode{Device: dev}
+	}
+
+	tree := &Tree{}
+	roots := map[string]*TreeRoot{}
+	for _, dev := range info.Devices {
+		node := nodes[dev.Address]
+		if parent, ok := nodes[dev.ParentAddress]; ok && dev.ParentAddress != dev.Address {
+			parent.Children = append(parent.Children, node)
+			continue
+		}
+		// devices without a known parent sit on a root bus
+		domain, bus := "", ""
+		if addr := pciaddr.FromString(dev.Address); addr != nil {
+			domain, bus = addr.Domain, addr.Bus
+		}
+		key := domain + ":" + bus
+		root, ok := roots[key]
+		if !ok {
+			root = &TreeRoot{Domain: domain, Bus: bus}
+			roots[key] = root
+			tree.Roots = append(tree.Roots, root)
+		}
+		root.Children = append(root.Children, node)
+	}
+
+	sort.Slice(tree.Roots, func(i, j int) bool {
+		if tree.Roots[i].Domain != tree.Roots[j].Domain {
+			return tree.Roots[i].Domain < tree.Roots[j].Domain
+		}
+		return tree.Roots[i].Bus < tree.Roots[j].Bus
+	})
+	for _, root := range tree.Roots {
+		sortTreeNodes(root.Children)
+	}
+	for _, node := range nodes {
+		sortTreeNodes(node.Children)
+	}
+	return tree
+}
+
+func sortTreeNodes(nodes []*TreeNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Device.Address < nodes[j].Device.Address
+	})
+}
+
+// Walk calls fn for every device of the tree, depth first, parents before
+// their children.
+func (t *Tree) Walk(fn WalkFunc) {
+	for _, root := range t.Roots {
+		walkTreeNodes(root.Children, 0, fn)
+	}
+}
+
+// Walk calls fn for the node and all the devices below it, depth first,
+// parents before their children. The node itself is at depth 0.
+func (n *TreeNode) Walk(fn WalkFunc) {
+	walkTreeNodes([]*TreeNode{n}, 0, fn)
+}
+
+func walkTreeNodes(nodes []*TreeNode, depth int, fn WalkFunc) {
+	for _, node := range nodes {
+		if fn(node, depth) {
+			walkTreeNodes(node.Children, depth+1, fn)
+		}
+	}
+}
+
+// Find returns the node of the device at the given address, or nil if the
+// device is not in the tree.
+func (t *Tree) Find(address string) *TreeNode {
+	var found *TreeNode
+	t.Walk(func(node *TreeNode, depth int) bool {
+		if found != nil {
+			return false
+		}
+		if node.Device.Address == address {
+			found = node
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// Subtree returns the tree node of the device at the given address, whose
+// children are the devices below it. Returns nil if no such device exists.
+func (info *Info) Subtree(address string) *TreeNode {
+	return info.Tree().Find(address)
+}
+
+// Ancestors returns the bridges between the device at the given address and
+// its root bus, nearest first. Returns nil if no such device exists.
+func (info *Info) Ancestors(address string) []*Device {
+	dev := info.lookupDevice(address)
+	if dev == nil {
+		return nil
+	}
+	ancestors := []*Device{}
+	seen := map[string]bool{dev.Address: true}
+	for {
+		parent := info.lookupDevice(dev.ParentAddress)
+		if parent == nil || seen[parent.Address] {
+			return ancestors
+		}
+		seen[parent.Address] = true
+		ancestors = append(ancestors, parent)
+		dev = parent
+	}
+}
+
+// String renders the tree in a format similar to the one of `lspci -t`
+func (t *Tree) String() string {
+	var sb strings.Builder
+	for _, root := range t.Roots {
+		fmt.Fprintf(&sb, "[%s:%s]\n", root.Domain, root.Bus)
+		writeTreeNodes(&sb, root.Children, "")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func writeTreeNodes(sb *strings.Builder, nodes []*TreeNode, prefix string) {
+	for idx, node := range nodes {
+		branch, indent := "+-", "| "
+		if idx == len(nodes)-1 {
+			branch, indent = "\\-", "  "
+		}
+		fmt.Fprintf(sb, "%s%s%s\n", prefix, branch, treeNodeLabel(node.Device))
+		writeTreeNodes(sb, node.Children, prefix+indent)
+	}
+}
+
+func treeNodeLabel(dev *Device) string {
+	addr := dev.Address
+	if pa := pciaddr.FromString(dev.Address); pa != nil {
+		addr = fmt.Sprintf("%s:%s.%s", pa.Bus, pa.Device, pa.Function)
+	}
+	kind := util.UNKNOWN
+	if dev.Subclass != nil && dev.Subclass.Name != util.UNKNOWN {
+		kind = dev.Subclass.Name
+	} else if dev.Class != nil {
+		kind = dev.Class.Name
+	}
+	vendorName := util.UNKNOWN
+	if dev.Vendor != nil {
+		vendorName = dev.Vendor.Name
+	}
+	productName := util.UNKNOWN
+	if dev.Product != nil {
+		productName = dev.Product.Name
+	}
+	return fmt.Sprintf("%s %s: %s %s", addr, kind, vendorName, productName)
+}
+
+// simple private struct used to encapsulate the PCI tree in a top-level
+// "pci_tree" YAML/JSON map/object key
+type treePrinter struct {
+	Tree *Tree `json:"pci_tree"`
+}
+
+// YAMLString returns a string with the PCI tree formatted as YAML under a
+// top-level "pci_tree:" key
+func (t *Tree) YAMLString() string {
+	return marshal.SafeYAML(treePrinter{t})
+}
+
+// JSONString returns a string with the PCI tree formatted as JSON under a
+// top-level "pci_tree:" key
+func (t *Tree) JSONString(indent bool) string {
+	return marshal.SafeJSON(treePrinter{t}, indent)
+}
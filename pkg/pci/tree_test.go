@@ -0,0 +1,111 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jaypipes/pcidb"
+
+	"github.com/zededa/ghw/pkg/pci"
+)
+
+func treeTestDevice(addr, parentAddr, subclass string) *pci.Device {
+	return &pci.Device{
+		Address:       addr,
+		ParentAddress: parentAddr,
+		Vendor:        &pcidb.Vendor{Name: "Vendor"},
+		Product:       &pcidb.Product{Name: "Product"},
+		Class:         &pcidb.Class{Name: "Class"},
+		Subclass:      &pcidb.Subclass{Name: subclass},
+	}
+}
+
+func TestPCITree(t *testing.T) {
+	info := &pci.Info{
+		Devices: []*pci.Device{
+			treeTestDevice("0000:00:00.0", "", "Host bridge"),
+			treeTestDevice("0000:00:1c.0", "", "PCI bridge"),
+			treeTestDevice("0000:00:1f.0", "", "ISA bridge"),
+			treeTestDevice("0000:01:00.0", "0000:00:1c.0", "PCI bridge"),
+			treeTestDevice("0000:02:01.0", "0000:01:00.0", "PCI bridge"),
+			treeTestDevice("0000:02:00.0", "0000:01:00.0", "PCI bridge"),
+			treeTestDevice("0000:03:00.0", "0000:02:00.0", "Serial controller"),
+			treeTestDevice("0000:04:00.0", "0000:02:01.0", "USB controller"),
+			treeTestDevice("0001:00:00.0", "", "Host bridge"),
+		},
+	}
+
+	tree := info.Tree()
+	if len(tree.Roots) != 2 {
+		t.Fatalf("expected 2 root buses, got %d", len(tree.Roots))
+	}
+
+	visited := []string{}
+	depths := []int{}
+	tree.Walk(func(node *pci.TreeNode, depth int) bool {
+		visited = append(visited, node.Device.Address)
+		depths = append(depths, depth)
+		return true
+	})
+	expectedVisited := []string{
+		"0000:00:00.0",
+		"0000:00:1c.0",
+		"0000:01:00.0",
+		"0000:02:00.0",
+		"0000:03:00.0",
+		"0000:02:01.0",
+		"0000:04:00.0",
+		"0000:00:1f.0",
+		"0001:00:00.0",
+	}
+	if !reflect.DeepEqual(visited, expectedVisited) {
+		t.Errorf("got walk order %v expected %v", visited, expectedVisited)
+	}
+	expectedDepths := []int{0, 0, 1, 2, 3, 2, 3, 0, 0}
+	if !reflect.DeepEqual(depths, expectedDepths) {
+		t.Errorf("got depths %v expected %v", depths, expectedDepths)
+	}
+
+	ancestors := []string{}
+	for _, dev := range info.Ancestors("0000:04:00.0") {
+		ancestors = append(ancestors, dev.Address)
+	}
+	expectedAncestors := []string{"0000:02:01.0", "0000:01:00.0", "0000:00:1c.0"}
+	if !reflect.DeepEqual(ancestors, expectedAncestors) {
+		t.Errorf("got ancestors %v expected %v", ancestors, expectedAncestors)
+	}
+
+	subtree := []string{}
+	info.Subtree("0000:01:00.0").Walk(func(node *pci.TreeNode, depth int) bool {
+		subtree = append(subtree, node.Device.Address)
+		return true
+	})
+	expectedSubtree := []string{"0000:01:00.0", "0000:02:00.0", "0000:03:00.0", "0000:02:01.0", "0000:04:00.0"}
+	if !reflect.DeepEqual(subtree, expectedSubtree) {
+		t.Errorf("got subtree %v expected %v", subtree, expectedSubtree)
+	}
+	if info.Subtree("0000:ff:00.0") != nil || info.Ancestors("0000:ff:00.0") != nil {
+		t.Errorf("expected nil results for unknown device")
+	}
+
+	expectedString := `[0000:00]
++-00:00.0 Host bridge: Vendor Product
++-00:1c.0 PCI bridge: Vendor Product
+| \-01:00.0 PCI bridge: Vendor Product
+|   +-02:00.0 PCI bridge: Vendor Product
+|   | \-03:00.0 Serial controller: Vendor Product
+|   \-02:01.0 PCI bridge: Vendor Product
+|     \-04:00.0 USB controller: Vendor Product
+\-00:1f.0 ISA bridge: Vendor Product
+[0001:00]
+\-00:00.0 Host bridge: Vendor Product`
+	if s := tree.String(); s != expectedString {
+		t.Errorf("got tree\n%s\nexpected\n%s", s, expectedString)
+	}
+}
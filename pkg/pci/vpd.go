@@ -0,0 +1,173 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Well-known VPD keywords
+const (
+	VPDKeywordPartNumber        = "PN"
+	VPDKeywordEngineeringChange = "EC"
+	VPDKeywordFabricGeometry    = "FG"
+	VPDKeywordLocation          = "LC"
+	VPDKeywordManufactureID     = "MN"
+	VPDKeywordPCIGeometry       = "PG"
+	VPDKeywordSerialNumber      = "SN"
+	VPDKeywordAssetTag          = "YA"
+)
+
+// VPD resource tags
+const (
+	vpdTagIdentifier = 0x02
+	vpdTagReadOnly   = 0x10
+	vpdTagReadWrite  = 0x11
+	vpdTagEnd        = 0x0f
+
+	// keywords holding the checksum and the unused space, not data
+	vpdKeywordChecksum  = "RV"
+	vpdKeywordRemaining = "RW"
+)
+
+// VPD holds the Vital Product Data of a PCI device, e.g. the part and serial
+// numbers of the board of a NIC or storage adapter.
+type VPD struct {
+	// The product name, from the identifier string
+	Identifier string `json:"identifier"`
+	// The read-only keywords (VPD-R), keyed by their two-character name,
+	// e.g. "PN", "SN" or "V0"
+	ReadOnly map[string]string `json:"read_only"`
+	// The read-write keywords (VPD-W), e.g. "YA" or "V1"
+	ReadWrite map[string]string `json:"read_write,omitempty"`
+}
+
+func (v *VPD) String() string {
+	return fmt.Sprintf("%s PN: %s SN: %s EC: %s", v.Identifier, v.PartNumber(), v.SerialNumber(), v.EngineeringChange())
+}
+
+// Keyword returns the value of the given keyword, looking at the read-only
+// keywords first, or an empty string if the keyword is not present.
+func (v *VPD) Keyword(keyword string) string {
+	if value, ok := v.ReadOnly[keyword]; ok {
+		return value
+	}
+	return v.ReadWrite[keyword]
+}
+
+// PartNumber returns the part number of the board
+func (v *VPD) PartNumber() string {
+	return v.Keyword(VPDKeywordPartNumber)
+}
+
+// SerialNumber returns the serial number of the board
+func (v *VPD) SerialNumber() string {
+	return v.Keyword(VPDKeywordSerialNumber)
+}
+
+// EngineeringChange returns the engineering change level of the board
+func (v *VPD) EngineeringChange() string {
+	return v.Keyword(VPDKeywordEngineeringChange)
+}
+
+// ParseVPD decodes the content of the vpd sysfs file of a PCI device, made of
+// small and large resource data items. Returns nil if no identifier string
+// or keyword could be decoded.
+func ParseVPD(data []byte) *VPD {
+	vpd := &VPD{
+		ReadOnly:  map[string]string{},
+		ReadWrite: map[string]string{},
+	}
+	found := false
+	for off := 0; off < len(data); {
+		tag := data[off]
+		if tag&0x80 == 0 {
+			// small resource: the length is in the tag
+			if (tag>>3)&0xf == vpdTagEnd {
+				break
+			}
+			off += 1 + int(tag&0x7)
+			continue
+		}
+		if off+3 > len(data) {
+			break
+		}
+		length := int(binary.LittleEndian.Uint16(data[off+1:]))
+		start := off + 3
+		end := start + length
+		if end > len(data) {
+			// devices often report a VPD size larger than the data they
+			// actually hold: stop at the first truncated item
+			break
+		}
+		switch tag & 0x7f {
+		case vpdTagIdentifier:
+			vpd.Identifier = vpdString(data[start:end])
+			found = true
+		case vpdTagReadOnly:
+			found = parseVPDKeywords(data[start:end], vpd.ReadOnly) || found
+		case vpdTagReadWrite:
+			found = parseVPDKeywords(data[start:end], vpd.ReadWrite) || found
+		}
+		off = end
+	}
+	if !found {
+		return nil
+	}
+	if len(vpd.ReadWrite) == 0 {
+		vpd.ReadWrite = nil
+	}
+	return vpd
+}
+
+// parseVPDKeywords decodes the keyword entries of a VPD-R or VPD-W resource,
+// each made of a two-character keyword, a length byte and the data. Returns
+// true if any keyword was found.
+func parseVPDKeywords(data []byte, keywords map[string]string) bool {
+	found := false
+	for off := 0; off+3 <= len(data); {
+		keyword := string(data[off : off+2])
+		start := off + 3
+		end := start + int(data[off+2])
+		if end > len(data) {
+			break
+		}
+		off = end
+		if keyword == vpdKeywordChecksum || keyword == vpdKeywordRemaining {
+			continue
+		}
+		if !isVPDKeyword(keyword) {
+			break
+		}
+		keywords[keyword] = vpdString(data[start:end])
+		found = true
+	}
+	return found
+}
+
+func isVPDKeyword(keyword string) bool {
+	for _, c := range keyword {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// vpdString returns the given VPD data as a string, trimmed of the padding
+// some vendors add. Binary data is rendered in hexadecimal.
+func vpdString(data []byte) string {
+	str := strings.TrimRight(string(data), " \x00")
+	for _, c := range []byte(str) {
+		if c < 0x20 || c > 0x7e {
+			return fmt.Sprintf("0x%x", data)
+		}
+	}
+	return strings.TrimSpace(str)
+}
@@ -0,0 +1,27 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/zededa/ghw/pkg/linuxpath"
+	pciaddr "github.com/zededa/ghw/pkg/pci/address"
+)
+
+// getDeviceVPD returns the Vital Product Data of the device at the given
+// address, or nil if the device has none. Reading the vpd file usually
+// requires root privileges.
+func getDeviceVPD(paths *linuxpath.Paths, pciAddr *pciaddr.Address) *VPD {
+	vpdPath := filepath.Join(paths.SysBusPciDevices, pciAddr.String(), "vpd")
+
+	data, err := os.ReadFile(vpdPath)
+	if err != nil {
+		return nil
+	}
+	return ParseVPD(data)
+}
@@ -0,0 +1,42 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci_test
+
+import (
+	"testing"
+
+	"github.com/zededa/ghw/pkg/option"
+	"github.com/zededa/ghw/pkg/pci"
+	"github.com/zededa/ghw/testdata"
+)
+
+func TestPCIVPD(t *testing.T) {
+	vpd := vpdTestResource(0x02, []byte("X710-4"))
+	vpd = append(vpd, vpdTestResource(0x10, vpdTestKeywords("SN", "A0B1C2D3E4F5", "RV", "\x00"))...)
+	vpd = append(vpd, 0x78)
+	root := pciTestSetupFakeSysfs(t, []pciTestDevice{
+		{
+			addr:  "0000:03:00.0",
+			attrs: map[string]string{"vpd": string(vpd)},
+		},
+	})
+	t.Setenv("PCIDB_PATH", testdata.PCIDBChroot())
+
+	// VPD is only read on request
+	info := pciTestLoad(t, root)
+	if info.Devices[0].VPD != nil {
+		t.Fatalf("expected no VPD by default, got %v", info.Devices[0].VPD)
+	}
+
+	info, err := pci.New(option.WithChroot(root), option.WithNullAlerter(), option.WithPCIVPD())
+	if err != nil {
+		t.Fatalf("Expected nil err, but got %v", err)
+	}
+	if info.Devices[0].VPD == nil || info.Devices[0].VPD.SerialNumber() != "A0B1C2D3E4F5" {
+		t.Fatalf("expected the VPD of the device, got %v", info.Devices[0].VPD)
+	}
+}
@@ -0,0 +1,91 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package pci_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zededa/ghw/pkg/pci"
+)
+
+// vpdTestResource returns a large resource data item with the given tag
+func vpdTestResource(tag byte, data []byte) []byte {
+	res := []byte{0x80 | tag, byte(len(data)), byte(len(data) >> 8)}
+	return append(res, data...)
+}
+
+// vpdTestKeywords returns the keyword entries of a VPD-R or VPD-W resource
+func vpdTestKeywords(kvs ...string) []byte {
+	data := []byte{}
+	for idx := 0; idx+1 < len(kvs); idx += 2 {
+		data = append(data, kvs[idx]...)
+		data = append(data, byte(len(kvs[idx+1])))
+		data = append(data, kvs[idx+1]...)
+	}
+	return data
+}
+
+func TestParseVPD(t *testing.T) {
+	data := vpdTestResource(0x02, []byte("Intel(R) Ethernet Converged Network Adapter X710-4 "))
+	data = append(data, vpdTestResource(0x10, vpdTestKeywords(
+		"V1", "Intel(R) Ethernet Converged Network Adapter X710-4",
+		"PN", "H58155-004",
+		"SN", "A0B1C2D3E4F5",
+		"EC", "G21437-002",
+		"V0", "\x01\x02",
+		"RV", "\x5a",
+	))...)
+	data = append(data, vpdTestResource(0x11, vpdTestKeywords(
+		"YA", "ASSET-42  ",
+		"RW", "\x00\x00\x00\x00",
+	))...)
+	data = append(data, 0x78)
+	// devices usually report more data than they hold
+	data = append(data, make([]byte, 64)...)
+
+	expected := &pci.VPD{
+		Identifier: "Intel(R) Ethernet Converged Network Adapter X710-4",
+		ReadOnly: map[string]string{
+			"V1": "Intel(R) Ethernet Converged Network Adapter X710-4",
+			"PN": "H58155-004",
+			"SN": "A0B1C2D3E4F5",
+			"EC": "G21437-002",
+			"V0": "0x0102",
+		},
+		ReadWrite: map[string]string{
+			"YA": "ASSET-42",
+		},
+	}
+	vpd := pci.ParseVPD(data)
+	if !reflect.DeepEqual(vpd, expected) {
+		t.Fatalf("got %+v expected %+v", vpd, expected)
+	}
+	if vpd.SerialNumber() != "A0B1C2D3E4F5" || vpd.PartNumber() != "H58155-004" ||
+		vpd.EngineeringChange() != "G21437-002" || vpd.Keyword(pci.VPDKeywordAssetTag) != "ASSET-42" {
+		t.Errorf("unexpected keyword values: %v", vpd)
+	}
+}
+
+func TestParseVPDInvalid(t *testing.T) {
+	tCases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty"},
+		{name: "unprogrammed", data: []byte{0xff, 0xff, 0xff, 0xff}},
+		{name: "end tag only", data: []byte{0x78}},
+		{name: "truncated identifier", data: []byte{0x82, 0x20, 0x00, 'A', 'B'}},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			if vpd := pci.ParseVPD(tCase.data); vpd != nil {
+				t.Errorf("expected nil VPD, got %+v", vpd)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package serial
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/zededa/ghw/pkg/option"
+	"github.com/zededa/ghw/pkg/serial/naming"
+)
+
+// usbInterfaceRe matches the sysfs name of a USB interface, capturing it
+// without the bus number, e.g. "2:1.0" for "1-2:1.0"
+var usbInterfaceRe = regexp.MustCompile(`^\d+-([\d.]+:\d+\.\d+)$`)
+
+// WithNaming sets the policy used to name serial ports, e.g. to take names
+// from caller-supplied rules or from where the ports are attached rather than
+// from legacy IO addresses and ACPI.
+func WithNaming(policy *naming.Policy) option.Option {
+	return func(opts *option.Options) {
+		opts.SerialNaming = policy
+	}
+}
+
+// namingPolicy returns the policy the given options set with WithNaming, nil
+// if none
+func namingPolicy(opts *option.Options) *naming.Policy {
+	policy, _ := opts.SerialNaming.(*naming.Policy)
+	return policy
+}
+
+// setNames names the given ports after the given policy, naming.Default()
+// if nil
+func setNames(devs []*Device, policy *naming.Policy) {
+	if policy == nil {
+		policy = naming.Default()
+	}
+	locations := locations(devs)
+	ports := make([]naming.Port, len(devs))
+	for i, dev := range devs {
+		ports[i] = naming.Port{
+			TTY:      strings.TrimPrefix(dev.Address, "/dev/"),
+			IOBase:   dev.ioBase,
+			ACPIHID:  dev.acpiHID,
+			ACPIUID:  dev.ACPIUID,
+			Path:     dev.Path,
+			Location: locations[i],
+		}
+	}
+	for i, name := range policy.Assign(ports) {
+		devs[i].Name = name.Name
+		devs[i].NameSource = name.Source
+	}
+}
+
+// locations returns the location names of the given ports. The ports of a
+// device with several, e.g. a multi-port PCI card, are told apart by their
+// index.
+func locations(devs []*Device) []string {
+	locs := make([]string, len(devs))
+	count := map[string]int{}
+	for i, dev := range devs {
+		locs[i] = location(dev)
+		count[locs[i]]++
+	}
+	index := map[string]int{}
+	for i, loc := range locs {
+		if loc == "" || count[loc] == 1 {
+			continue
+		}
+		locs[i] = fmt.Sprintf("%s-%d", loc, index[loc])
+		index[loc]++
+	}
+	return locs
+}
+
+// location returns the name of the given port after where it is attached,
+// like the links udev creates in /dev/serial/by-path, e.g.
+// "pci-0000:00:14.0-usb-2:1.0" for a USB adapter, "pci-0000:05:00.0" for a
+// PCI card or "platform-9000000.pl011" for a SoC UART. Returns an empty
+// string if the sysfs path of the port is unknown.
+func location(dev *Device) string {
+	if dev.Path == "" {
+		return ""
+	}
+	var parts []string
+	if dev.Parent.PCI != nil {
+		parts = append(parts, "pci-"+dev.Parent.PCI.String())
+	}
+	if dev.Parent.USB != nil {
+		port := dev.Parent.USB.Port
+		for _, dir := range strings.Split(dev.Path, "/") {
+			if m := usbInterfaceRe.FindStringSubmatch(dir); m != nil {
+				port = m[1]
+			}
+		}
+		parts = append(parts, "usb-"+port)
+	}
+	if len(parts) == 0 {
+		// e.g. "platform/soc/9000000.pl011" or "pnp0/00:01"
+		dirs := strings.Split(dev.Path, "/")
+		parts = append(parts, strings.TrimRight(dirs[0], "0123456789"), dirs[len(dirs)-1])
+	}
+	return strings.Join(parts, "-")
+}
@@ -0,0 +1,236 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// Package naming assigns serial ports names which do not depend on the order
+// the kernel enumerates them in, e.g. COM1 for the port at IO address 0x3f8.
+package naming
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Source is where the name of a serial port comes from.
+type Source string
+
+const (
+	// SourceRules names ports after the first matching caller-supplied Rule
+	SourceRules Source = "rules"
+	// SourceIOBase names the ports at the legacy PC IO addresses COM1 to
+	// COM4: 0x3f8, 0x2f8, 0x3e8 and 0x2e8
+	SourceIOBase Source = "io-base"
+	// SourceACPIUID names a port COMn after its ACPI _UID n, as firmware
+	// numbers its COM ports from 1. Only the 8250 compatible ports the
+	// firmware describes as such, with _HID PNP0500 or PNP0501, are named
+	// this way, and those with _UID 0 are not.
+	SourceACPIUID Source = "acpi-uid"
+	// SourceLocation names a port after where it is attached, e.g.
+	// "pci-0000:00:14.0-usb-2:1.0" for a USB adapter in port 2 of the
+	// controller at 0000:00:14.0
+	SourceLocation Source = "location"
+	// SourceSequential names a port COMn after the lowest n no other port
+	// uses. This is the fallback for the ports no other source names, and
+	// the names it gives can change when ports appear or disappear.
+	SourceSequential Source = "sequential"
+)
+
+// ioBaseNames are the names of the legacy PC serial ports, keyed by IO
+// address
+var ioBaseNames = map[uint64]string{
+	0x3f8: "COM1",
+	0x2f8: "COM2",
+	0x3e8: "COM3",
+	0x2e8: "COM4",
+}
+
+// comHIDs are the ACPI _HIDs of the 8250 compatible COM ports: the _UIDs of
+// other devices, e.g. of PCI or USB ones, number them among their own kind
+var comHIDs = map[string]bool{
+	"PNP0500": true,
+	"PNP0501": true,
+}
+
+// Rule names the serial port matching all its non-empty keys.
+type Rule struct {
+	// IOBase matches the port at the given IO address, e.g. 0x3f8
+	IOBase uint64 `json:"io_base,omitempty"`
+	// Path matches the ports whose device is at, or below, the given sysfs
+	// path relative to /sys/devices, e.g. "pci0000:00/0000:00:16.3" or
+	// "pci0000:00/0000:00:14.0/usb1/1-2". Shell patterns are allowed.
+	Path string `json:"path,omitempty"`
+	// Name is the name given to the matching port
+	Name string `json:"name"`
+}
+
+func (r *Rule) matches(port *Port) bool {
+	if r.IOBase == 0 && r.Path == "" {
+		return false
+	}
+	if r.IOBase != 0 && r.IOBase != port.IOBase {
+		return false
+	}
+	return r.Path == "" || matchPath(r.Path, port.Path)
+}
+
+// matchPath returns true if path, or one of its parents, matches pattern
+func matchPath(pattern, path string) bool {
+	for path != "" && path != "." && path != "/" {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		path = filepath.Dir(path)
+	}
+	return false
+}
+
+// Port holds what the sources know about a serial port.
+type Port struct {
+	// The tty of the port, e.g. "ttyS0"
+	TTY string
+	// The IO address of the port, 0 if it is not accessed through IO ports
+	IOBase uint64
+	// The ACPI _HID of the device of the port, e.g. "PNP0501", empty if none
+	ACPIHID string
+	// The ACPI _UID of the device of the port, empty if none
+	ACPIUID string
+	// The sysfs path of the device of the port, relative to /sys/devices,
+	// e.g. "pnp0/00:01"
+	Path string
+	// The name of the port after where it is attached, empty if unknown
+	Location string
+}
+
+// Name is the name assigned to a port, and where it comes from.
+type Name struct {
+	Name   string `json:"name"`
+	Source Source `json:"source"`
+}
+
+// Policy tells how to name serial ports.
+type Policy struct {
+	// The sources to take names from, by order of preference. The ports
+	// none of them names are named by SourceSequential.
+	Sources []Source `json:"sources"`
+	// The rules SourceRules applies, the first matching one wins
+	Rules []Rule `json:"rules,omitempty"`
+}
+
+// Default returns the policy ghw uses unless told otherwise: caller rules,
+// then legacy IO addresses, then ACPI _UIDs.
+func Default() *Policy {
+	return &Policy{
+		Sources: []Source{SourceRules, SourceIOBase, SourceACPIUID},
+	}
+}
+
+// FromString returns the policy taking names from the given comma-separated
+// sources, e.g. "io-base,location".
+func FromString(sources string) (*Policy, error) {
+	p := &Policy{}
+	for _, s := range strings.Split(sources, ",") {
+		source := Source(strings.TrimSpace(s))
+		switch source {
+		case SourceRules, SourceIOBase, SourceACPIUID, SourceLocation, SourceSequential:
+			p.Sources = append(p.Sources, source)
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown serial port naming source %q", s)
+		}
+	}
+	return p, nil
+}
+
+// RuleFromString returns the rule described by the given string: the name,
+// then "=" and the comma-separated keys to match, e.g. "console=io:0x3f8" or
+// "plc=path:pci0000:00/0000:00:14.0/usb1/1-2".
+func RuleFromString(rule string) (*Rule, error) {
+	name, keys, found := strings.Cut(rule, "=")
+	if !found || name == "" {
+		return nil, fmt.Errorf("serial port naming rule %q has no name", rule)
+	}
+	r := &Rule{Name: name}
+	for _, key := range strings.Split(keys, ",") {
+		k, v, _ := strings.Cut(key, ":")
+		switch k {
+		case "io":
+			ioBase, err := strconv.ParseUint(v, 0, 64)
+			if err != nil || ioBase == 0 {
+				return nil, fmt.Errorf("invalid IO address %q in serial port naming rule %q", v, rule)
+			}
+			r.IOBase = ioBase
+		case "path":
+			if _, err := filepath.Match(v, ""); err != nil || v == "" {
+				return nil, fmt.Errorf("invalid path %q in serial port naming rule %q", v, rule)
+			}
+			r.Path = v
+		default:
+			return nil, fmt.Errorf("unknown key %q in serial port naming rule %q", k, rule)
+		}
+	}
+	return r, nil
+}
+
+// Assign returns the name of each of the given ports. Each source in turn
+// names the ports the previous ones did not, skipping the names already
+// taken, so no two ports get the same name.
+func (p *Policy) Assign(ports []Port) []Name {
+	names := make([]Name, len(ports))
+	taken := map[string]bool{}
+	for _, source := range append(append([]Source{}, p.Sources...), SourceSequential) {
+		for i := range ports {
+			if names[i].Name != "" {
+				continue
+			}
+			name := p.name(source, &ports[i], taken)
+			if name == "" || taken[name] {
+				continue
+			}
+			names[i] = Name{Name: name, Source: source}
+			taken[name] = true
+		}
+	}
+	return names
+}
+
+// acpiUID returns the ACPI _UID of the given port, if it is a COM port
+// numbered by firmware
+func acpiUID(port *Port) (uint64, bool) {
+	if !comHIDs[port.ACPIHID] {
+		return 0, false
+	}
+	uid, err := strconv.ParseUint(port.ACPIUID, 0, 16)
+	return uid, err == nil
+}
+
+// name returns the name the given source gives to the given port, empty if
+// none
+func (p *Policy) name(source Source, port *Port, taken map[string]bool) string {
+	switch source {
+	case SourceRules:
+		for i := range p.Rules {
+			if p.Rules[i].matches(port) {
+				return p.Rules[i].Name
+			}
+		}
+	case SourceIOBase:
+		return ioBaseNames[port.IOBase]
+	case SourceACPIUID:
+		if uid, ok := acpiUID(port); ok && uid > 0 {
+			return fmt.Sprintf("COM%d", uid)
+		}
+	case SourceLocation:
+		return port.Location
+	case SourceSequential:
+		for n := 1; ; n++ {
+			if name := fmt.Sprintf("COM%d", n); !taken[name] {
+				return name
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package naming
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAssign(t *testing.T) {
+	ports := []Port{
+		{TTY: "ttyS0", IOBase: 0x3f8, ACPIHID: "PNP0501", ACPIUID: "0", Path: "pnp0/00:01"},
+		{TTY: "ttyS1", IOBase: 0x2f8, ACPIHID: "PNP0501", ACPIUID: "2", Path: "pnp0/00:02"},
+		{TTY: "ttyS4", IOBase: 0xe000, ACPIHID: "INT3F0D", ACPIUID: "1", Path: "pci0000:00/0000:00:16.3", Location: "pci-0000:00:16.3"},
+		{TTY: "ttyUSB0", Path: "pci0000:00/0000:00:14.0/usb1/1-2/1-2:1.0/ttyUSB0", Location: "pci-0000:00:14.0-usb-2:1.0"},
+		{TTY: "ttyUSB1", Path: "pci0000:00/0000:00:14.0/usb1/1-3/1-3:1.0/ttyUSB1", Location: "pci-0000:00:14.0-usb-3:1.0"},
+	}
+	tests := []struct {
+		name     string
+		policy   *Policy
+		expected []Name
+	}{
+		{
+			name:   "default",
+			policy: Default(),
+			expected: []Name{
+				{"COM1", SourceIOBase},
+				{"COM2", SourceIOBase},
+				{"COM3", SourceSequential},
+				{"COM4", SourceSequential},
+				{"COM5", SourceSequential},
+			},
+		},
+		{
+			name: "rules",
+			policy: &Policy{
+				Sources: []Source{SourceRules, SourceIOBase, SourceLocation},
+				Rules: []Rule{
+					{IOBase: 0x2f8, Name: "console"},
+					{Path: "pci0000:00/0000:00:14.0/usb1/1-2", Name: "plc"},
+					{Path: "pci0000:00/0000:00:16.3", Name: "amt"},
+					{IOBase: 0xe000, Path: "pnp0/*", Name: "never"},
+				},
+			},
+			expected: []Name{
+				{"COM1", SourceIOBase},
+				{"console", SourceRules},
+				{"amt", SourceRules},
+				{"plc", SourceRules},
+				{"pci-0000:00:14.0-usb-3:1.0", SourceLocation},
+			},
+		},
+		{
+			// _UID 0 names no port, nor does the _UID of a device which
+			// is not a COM port
+			name:   "acpi-uid",
+			policy: &Policy{Sources: []Source{SourceACPIUID}},
+			expected: []Name{
+				{"COM1", SourceSequential},
+				{"COM2", SourceACPIUID},
+				{"COM3", SourceSequential},
+				{"COM4", SourceSequential},
+				{"COM5", SourceSequential},
+			},
+		},
+		{
+			name: "duplicate names",
+			policy: &Policy{
+				Sources: []Source{SourceRules},
+				Rules:   []Rule{{Path: "pci0000:00/0000:00:14.0", Name: "COM1"}},
+			},
+			expected: []Name{
+				{"COM2", SourceSequential},
+				{"COM3", SourceSequential},
+				{"COM4", SourceSequential},
+				{"COM1", SourceRules},
+				{"COM5", SourceSequential},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			names := test.policy.Assign(ports)
+			if !reflect.DeepEqual(names, test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, names)
+			}
+		})
+	}
+}
+
+func TestFromString(t *testing.T) {
+	p, err := FromString("io-base, location")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.Sources, []Source{SourceIOBase, SourceLocation}) {
+		t.Fatalf("unexpected sources %v", p.Sources)
+	}
+	if _, err := FromString("io-base,bogus"); err == nil {
+		t.Fatal("expected an error for an unknown source")
+	}
+}
+
+func TestRuleFromString(t *testing.T) {
+	r, err := RuleFromString("plc=io:0x3f8,path:pci0000:00/0000:00:14.0/usb1/1-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &Rule{IOBase: 0x3f8, Path: "pci0000:00/0000:00:14.0/usb1/1-2", Name: "plc"}
+	if !reflect.DeepEqual(r, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, r)
+	}
+	for _, rule := range []string{"io:0x3f8", "=io:0x3f8", "plc=", "plc=io:com1", "plc=path:[", "plc=bogus:1"} {
+		if _, err := RuleFromString(rule); err == nil {
+			t.Errorf("expected an error for rule %q", rule)
+		}
+	}
+}
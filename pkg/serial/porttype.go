@@ -0,0 +1,106 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package serial
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PortType is the family of the driver handling a serial port.
+type PortType string
+
+const (
+	PortTypeUnknown PortType = "unknown"
+	// PortType8250 is a 8250/16550 compatible UART, e.g. ttyS0, be it on the
+	// LPC bus, on a PCI card or memory mapped on an ARM SoC
+	PortType8250 PortType = "8250"
+	// PortTypePL011 is an ARM PrimeCell PL011 UART, e.g. ttyAMA0
+	PortTypePL011 PortType = "pl011"
+	// PortTypeIMX is a NXP i.MX UART, e.g. ttymxc0
+	PortTypeIMX PortType = "imx"
+	// PortTypeLPUART is a NXP Low Power UART, e.g. ttyLP0
+	PortTypeLPUART PortType = "lpuart"
+	// PortTypeTegra is a NVIDIA Tegra high speed UART, e.g. ttyTHS0
+	PortTypeTegra PortType = "tegra"
+	// PortTypeUSBSerial is a USB to serial adapter handled by one of the
+	// usb-serial drivers, e.g. ftdi_sio, cp210x or pl2303, e.g. ttyUSB0
+	PortTypeUSBSerial PortType = "usb-serial"
+	// PortTypeCDCACM is a USB CDC ACM device, e.g. a modem or a
+	// microcontroller board, e.g. ttyACM0
+	PortTypeCDCACM PortType = "cdc-acm"
+)
+
+// portTypeByDriver maps the name of the driver bound to the device of a tty
+// to the family of the driver
+var portTypeByDriver = map[string]PortType{
+	"serial8250":   PortType8250,
+	"serial":       PortType8250, // 8250_pnp and 8250_pci
+	"exar_serial":  PortType8250,
+	"of_serial":    PortType8250,
+	"dw-apb-uart":  PortType8250,
+	"omap8250":     PortType8250,
+	"uart-pl011":   PortTypePL011,
+	"imx-uart":     PortTypeIMX,
+	"fsl-lpuart":   PortTypeLPUART,
+	"serial-tegra": PortTypeTegra,
+	"cdc_acm":      PortTypeCDCACM,
+}
+
+// portTypeByName maps the name of a tty, without its index, to the family of
+// the driver creating it, for the ttys whose driver is unknown
+var portTypeByName = map[string]PortType{
+	"ttyS":   PortType8250,
+	"ttyAMA": PortTypePL011,
+	"ttymxc": PortTypeIMX,
+	"ttyLP":  PortTypeLPUART,
+	"ttyTHS": PortTypeTegra,
+	"ttyUSB": PortTypeUSBSerial,
+	"ttyACM": PortTypeCDCACM,
+}
+
+// portType returns the family of the driver of the given tty. isUSB tells
+// whether the tty sits behind a USB device, which makes any driver other
+// than cdc_acm a usb-serial one.
+func portType(tty, driver string, isUSB bool) PortType {
+	if driver != "" {
+		if t, ok := portTypeByDriver[driver]; ok {
+			return t
+		}
+		if isUSB {
+			return PortTypeUSBSerial
+		}
+		if strings.Contains(driver, "8250") {
+			return PortType8250
+		}
+	}
+	if t, ok := portTypeByName[ttyPrefix(tty)]; ok {
+		return t
+	}
+	return PortTypeUnknown
+}
+
+// ttyPrefix returns the name of a tty without its index, e.g. "ttyUSB" for
+// "ttyUSB1"
+func ttyPrefix(tty string) string {
+	return strings.TrimRight(tty, "0123456789")
+}
+
+// ttyLess orders ttys by name then by index, so that ttyS2 comes before
+// ttyS10
+func ttyLess(a, b string) bool {
+	pa, pb := ttyPrefix(a), ttyPrefix(b)
+	if pa != pb {
+		return pa < pb
+	}
+	ia, errA := strconv.Atoi(a[len(pa):])
+	ib, errB := strconv.Atoi(b[len(pb):])
+	if errA != nil || errB != nil {
+		return a < b
+	}
+	return ia < ib
+}
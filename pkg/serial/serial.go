@@ -11,18 +11,56 @@ import (
 	"github.com/zededa/ghw/pkg/bus"
 	"github.com/zededa/ghw/pkg/marshal"
 	"github.com/zededa/ghw/pkg/option"
+	"github.com/zededa/ghw/pkg/serial/naming"
 )
 
 type Device struct {
-	Name    string        `json:"name"`
-	Address string        `json:"address"`
-	IO      string        `json:"io"`
-	IRQ     string        `json:"irq"`
-	Parent  bus.BusParent `json:"parent,omitempty"`
+	Name string `json:"name"`
+	// Where the name comes from, telling whether it is stable
+	NameSource naming.Source `json:"name_source"`
+	Address    string        `json:"address"`
+	// The family of the driver handling the port, e.g. "8250" or "usb-serial"
+	Type PortType `json:"type"`
+	// The driver bound to the device of the port, e.g. "serial8250",
+	// "uart-pl011" or "ftdi_sio", empty if unknown
+	Driver string        `json:"driver,omitempty"`
+	IO     string        `json:"io"`
+	IRQ    string        `json:"irq"`
+	Parent bus.BusParent `json:"parent,omitempty"`
+	// The ACPI _UID of the device of the port, empty if none
+	ACPIUID string `json:"acpi_uid,omitempty"`
+	// The sysfs path of the device of the port, relative to /sys/devices,
+	// e.g. "pnp0/00:01" or "pci0000:00/0000:00:14.0/usb1/1-2/1-2:1.0/ttyUSB0"
+	Path string `json:"path,omitempty"`
+	// The UART model, e.g. "16550A" or "PL011 rev2", empty if unknown or if
+	// no UART answers at the address of the port
+	UART string `json:"uart,omitempty"`
+	// The frequency of the UART clock in Hz (uartclk), e.g. 1843200
+	UARTClock uint64 `json:"uartclk_hz,omitempty"`
+	// The size of the transmit FIFO in bytes (xmit_fifo_size)
+	FIFOSize int `json:"fifo_size,omitempty"`
+	// The UPF_* flags of the port, in hexadecimal, e.g. "0x10000040"
+	Flags string `json:"flags,omitempty"`
+	// True if the port is a kernel console: passing it to a guest takes the
+	// console away from the host
+	Console bool `json:"console"`
+	// The RS-485 configuration of the port in the device tree, nil if none
+	RS485 *RS485 `json:"rs485,omitempty"`
+	// The IO address of the port, 0 if it is memory mapped or not a UART
+	ioBase uint64
+	// The ACPI _HID of the device of the port, e.g. "PNP0501", empty if none
+	acpiHID string
 }
 
 func (d Device) String() string {
-	return fmt.Sprintf("%s (address: %s) (io: %s) (irq: %s) (parent: %+v)", d.Name, d.Address, d.IO, d.IRQ, d.Parent)
+	str := fmt.Sprintf("%s (address: %s) (type: %s) (driver: %s) (uart: %s) (io: %s) (irq: %s) (parent: %+v)", d.Name, d.Address, d.Type, d.Driver, d.UART, d.IO, d.IRQ, d.Parent)
+	if d.RS485 != nil {
+		str += " (rs485)"
+	}
+	if d.Console {
+		str += " (console)"
+	}
+	return str
 }
 
 type Info struct {
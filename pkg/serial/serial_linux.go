@@ -6,7 +6,6 @@
 package serial
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -15,11 +14,11 @@ import (
 	"strings"
 
 	"github.com/zededa/ghw/pkg/bus"
+	"github.com/zededa/ghw/pkg/console"
 	"github.com/zededa/ghw/pkg/linuxpath"
 	"github.com/zededa/ghw/pkg/option"
+	"github.com/zededa/ghw/pkg/pci"
 	pciAddress "github.com/zededa/ghw/pkg/pci/address"
-	"github.com/zededa/ghw/pkg/usb"
-	usbAddress "github.com/zededa/ghw/pkg/usb/address"
 )
 
 func (i *Info) load(opts *option.Options) error {
@@ -36,49 +35,61 @@ func (i *Info) load(opts *option.Options) error {
 func serials(opts *option.Options) ([]*Device, []error) {
 	paths := linuxpath.New(opts)
 	ttyClass := paths.SysClassTty
-	ttys, err := filepath.Glob(filepath.Join(ttyClass, "ttyS*"))
+	entries, err := os.ReadDir(ttyClass)
 	if err != nil {
 		return nil, []error{err}
 	}
+	ttys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ttys = append(ttys, entry.Name())
+	}
 
-	// Deterministic order: ttyS0, ttyS1, ...
+	// Deterministic order: ttyACM0, ..., ttyS0, ttyS1, ..., ttyUSB0, ...
 	sort.Slice(ttys, func(i, j int) bool {
-		return filepath.Base(ttys[i]) < filepath.Base(ttys[j])
+		return ttyLess(ttys[i], ttys[j])
 	})
 
+	uarts := procUARTs(paths)
+	consoles := console.New(paths)
+
 	var out []*Device
-	id := 1
-	for _, ttyDir := range ttys {
-		tty := filepath.Base(ttyDir) // ttyS1
+	for _, tty := range ttys {
 		sp, ok, err := serialPortFromTTY(paths.SysRoot, ttyClass, tty)
 		if err != nil {
 			continue
 		}
 		if ok {
-			sp.Name = fmt.Sprintf("COM%d", id)
+			if uart, found := uarts[tty]; found {
+				sp.UART = uart
+			}
+			sp.Console = consoles.IsConsole(paths, tty)
 			out = append(out, sp)
-			id++
 		}
 	}
+	setNames(out, namingPolicy(opts))
 	return out, nil
 }
 
 func serialPortFromTTY(sysfs, ttyClass, tty string) (*Device, bool, error) {
 	ttyDir := filepath.Join(ttyClass, tty)
 
-	// Must have /sys/class/tty/<tty>/device symlink to be hardware-backed.
+	// Must have /sys/class/tty/<tty>/device symlink to be hardware-backed,
+	// which leaves out virtual consoles, ptys and the like.
 	devLink := filepath.Join(ttyDir, "device")
 	devSys, err := filepath.EvalSymlinks(devLink)
 	if err != nil {
 		return nil, false, nil
 	}
+	devSys = hardwareDevice(devSys)
 
 	irq, _ := readUintDecimal(filepath.Join(ttyDir, "irq"))
 	ioType, _ := readUintDecimal(filepath.Join(ttyDir, "io_type"))
 	portBase, portOK := readUintHex(filepath.Join(ttyDir, "port"))
 
 	ioRange := ""
+	ioBase := uint64(0)
 	if portOK && isIOPortUART(ioType) {
+		ioBase = portBase
 		start := portBase
 		end := portBase + 7 // 8250 register block: 8 bytes
 
@@ -97,34 +108,66 @@ func serialPortFromTTY(sysfs, ttyClass, tty string) (*Device, bool, error) {
 		ioRange = fmt.Sprintf("%04x-%04x", start, end)
 	}
 
-	var parent bus.BusParent
-	if pciAddr := pciAddress.FromString(devSys); pciAddr != nil {
-		parent.PCI = pciAddr
+	driver := ""
+	if driverDir, err := filepath.EvalSymlinks(filepath.Join(devSys, "driver")); err == nil {
+		driver = filepath.Base(driverDir)
 	}
-	bus, port, err := usb.ExtractUSBBusnumPort(devSys)
-	if err == nil {
-		parent.USB = &usbAddress.Address{
-			Busnum: bus,
-			Port:   port,
-		}
+
+	path := ""
+	if rel, err := filepath.Rel(filepath.Join(sysfs, "devices"), devSys); err == nil && !strings.HasPrefix(rel, "..") {
+		path = rel
 	}
 
 	sp := &Device{
 		Address: "/dev/" + tty,
+		Driver:  driver,
 		IO:      ioRange,
 		IRQ:     fmt.Sprintf("%d", irq),
-		Parent:  parent,
+		Parent:  bus.ParentFromPath(devSys),
+		ACPIUID: slurp(filepath.Join(devSys, "firmware_node", "uid")),
+		Path:    path,
+		Flags:   slurp(filepath.Join(ttyDir, "flags")),
+		RS485:   getRS485(devSys),
+		ioBase:  ioBase,
+		acpiHID: slurp(filepath.Join(devSys, "firmware_node", "hid")),
+	}
+	sp.Type = portType(tty, driver, sp.Parent.USB != nil)
+	sp.UARTClock, _ = readUintDecimal(filepath.Join(ttyDir, "uartclk"))
+	if fifoSize, err := readUintDecimal(filepath.Join(ttyDir, "xmit_fifo_size")); err == nil {
+		sp.FIFOSize = int(fifoSize)
+	}
+	if sp.Type == PortType8250 {
+		// PORT_* numbers are global, from serial_core.h, but uartTypes
+		// only names the 8250 models: the models of the other drivers come
+		// from /proc/tty/driver
+		if portType, err := readUintDecimal(filepath.Join(ttyDir, "type")); err == nil {
+			sp.UART = uartType(portType)
+		}
 	}
 	return sp, true, nil
 }
 
+// hardwareDevice returns the device of the UART itself for the given tty
+// device. Since Linux 6.5 the tty device is a port device of the serial-base
+// bus, which sits below a controller device of the same bus, e.g.
+// /sys/devices/pnp0/00:01/00:01:0/00:01:0.0 for the UART /sys/devices/pnp0/00:01
+func hardwareDevice(devSys string) string {
+	for {
+		subsystem, err := filepath.EvalSymlinks(filepath.Join(devSys, "subsystem"))
+		if err != nil || filepath.Base(subsystem) != "serial-base" {
+			return devSys
+		}
+		devSys = filepath.Dir(devSys)
+	}
+}
+
 func isIOPortUART(ioType uint64) bool {
 	// For ttyS* this is commonly 0 for IO port access.
 	return ioType == 0
 }
 
 func findPCIDeviceDirFromResolvedDevice(sysfs, devSys string) (string, bool) {
-	addr := pciAddress.FromString(devSys)
+	addr := pciAddress.FromPath(devSys)
 	if addr == nil {
 		return "", false
 	}
@@ -182,53 +225,30 @@ func readUintHex(path string) (uint64, bool) {
 	return v, true
 }
 
+func slurp(path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
 func statOK(path string) bool {
 	_, err := os.Stat(path)
 	return err == nil
 }
 
-// Parse <pci>/resource and find the IO BAR that contains port.
-// resource format: "start end flags" per line, hex.
+// findContainingPCIIoBAR finds the IO BAR of the PCI device that contains
+// port.
 func findContainingPCIIoBAR(pciDevDir string, port uint64) (start, end uint64, ok bool) {
-	f, err := os.Open(filepath.Join(pciDevDir, "resource"))
+	data, err := os.ReadFile(filepath.Join(pciDevDir, "resource"))
 	if err != nil {
 		return 0, 0, false
 	}
-	defer f.Close()
-
-	const IORESOURCE_IO = 0x00000100
-
-	sc := bufio.NewScanner(f)
-	for sc.Scan() {
-		line := strings.TrimSpace(sc.Text())
-		if line == "" {
-			continue
-		}
-		fields := strings.Fields(line)
-		if len(fields) < 3 {
-			continue
-		}
-		st, err0 := parseHex(fields[0])
-		en, err1 := parseHex(fields[1])
-		fl, err2 := parseHex(fields[2])
-		if err0 != nil || err1 != nil || err2 != nil {
-			continue
-		}
-		if st == 0 && en == 0 {
-			continue
-		}
-		if (fl & IORESOURCE_IO) == 0 {
-			continue
-		}
-		if port >= st && port <= en {
-			return st, en, true
+	for _, res := range pci.ParseResources(data) {
+		if res.IO && res.Contains(port) {
+			return res.Start, res.End, true
 		}
 	}
 	return 0, 0, false
 }
-
-func parseHex(s string) (uint64, error) {
-	s = strings.TrimSpace(s)
-	s = strings.TrimPrefix(s, "0x")
-	return strconv.ParseUint(s, 16, 64)
-}
@@ -11,9 +11,11 @@ package serial
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/zededa/ghw/pkg/option"
+	"github.com/zededa/ghw/pkg/serial/naming"
 )
 
 func TestSerial(t *testing.T) {
@@ -106,3 +108,238 @@ func TestSerial(t *testing.T) {
 		}
 	}
 }
+
+func TestSerialPortTypes(t *testing.T) {
+	root, err := os.MkdirTemp("", "ghw-serial-test-")
+	if err != nil {
+		t.Fatalf("could not create temp directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	sysDevices := filepath.Join(root, "sys", "devices")
+	ttyDir := filepath.Join(root, "sys", "class", "tty")
+	driversDir := filepath.Join(root, "sys", "bus", "drivers")
+	for _, tty := range []struct {
+		name   string
+		device string
+		driver string
+	}{
+		{"ttyAMA0", "platform/9000000.pl011", "uart-pl011"},
+		{"ttymxc1", "platform/soc@0/30890000.serial", ""},
+		{"ttyUSB0", "pci0000:00/0000:00:14.0/usb1/1-2/1-2:1.0/ttyUSB0", "ftdi_sio"},
+		{"ttyACM0", "pci0000:00/0000:00:14.0/usb1/1-3/1-3:1.0", "cdc_acm"},
+		{"ttyS10", "platform/serial8250", "serial8250"},
+		{"ttyS2", "platform/serial8250", "serial8250"},
+		// serial-base port device, since Linux 6.5
+		{"ttyS3", "pnp0/00:01/00:01:0/00:01:0.0", "port"},
+		// virtual console, no device link
+		{"tty0", "", ""},
+	} {
+		if err := os.MkdirAll(filepath.Join(ttyDir, tty.name), 0755); err != nil {
+			t.Fatalf("could not create %s directory: %v", tty.name, err)
+		}
+		if tty.device == "" {
+			continue
+		}
+		devDir := filepath.Join(sysDevices, tty.device)
+		if err := os.MkdirAll(devDir, 0755); err != nil {
+			t.Fatalf("could not create %s device directory: %v", tty.name, err)
+		}
+		if err := os.Symlink(devDir, filepath.Join(ttyDir, tty.name, "device")); err != nil {
+			t.Fatalf("could not link %s device: %v", tty.name, err)
+		}
+		if tty.driver == "" {
+			continue
+		}
+		driverDir := filepath.Join(driversDir, tty.driver)
+		if err := os.MkdirAll(driverDir, 0755); err != nil {
+			t.Fatalf("could not create %s driver directory: %v", tty.name, err)
+		}
+		if err := os.Symlink(driverDir, filepath.Join(devDir, "driver")); err != nil && !os.IsExist(err) {
+			t.Fatalf("could not link %s driver: %v", tty.name, err)
+		}
+	}
+
+	// the UART behind the serial-base devices of ttyS3
+	serialBase := filepath.Join(root, "sys", "bus", "serial-base")
+	if err := os.MkdirAll(serialBase, 0755); err != nil {
+		t.Fatalf("could not create serial-base directory: %v", err)
+	}
+	for _, dir := range []string{"pnp0/00:01/00:01:0", "pnp0/00:01/00:01:0/00:01:0.0"} {
+		if err := os.Symlink(serialBase, filepath.Join(sysDevices, dir, "subsystem")); err != nil {
+			t.Fatalf("could not link %s subsystem: %v", dir, err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(driversDir, "serial"), 0755); err != nil {
+		t.Fatalf("could not create serial driver directory: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(driversDir, "serial"), filepath.Join(sysDevices, "pnp0/00:01", "driver")); err != nil {
+		t.Fatalf("could not link ttyS3 driver: %v", err)
+	}
+	firmwareNode := filepath.Join(sysDevices, "pnp0/00:01", "firmware_node")
+	if err := os.MkdirAll(firmwareNode, 0755); err != nil {
+		t.Fatalf("could not create ttyS3 firmware node: %v", err)
+	}
+	for name, content := range map[string]string{"hid": "PNP0501\n", "uid": "3\n"} {
+		if err := os.WriteFile(filepath.Join(firmwareNode, name), []byte(content), 0644); err != nil {
+			t.Fatalf("could not write ttyS3 firmware node %s: %v", name, err)
+		}
+	}
+
+	info, err := New(option.WithChroot(root))
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+
+	expected := []struct {
+		address string
+		typ     PortType
+		driver  string
+		usb     string
+	}{
+		{"/dev/ttyACM0", PortTypeCDCACM, "cdc_acm", "1-3"},
+		{"/dev/ttyAMA0", PortTypePL011, "uart-pl011", ""},
+		{"/dev/ttyS2", PortType8250, "serial8250", ""},
+		{"/dev/ttyS3", PortType8250, "serial", ""},
+		{"/dev/ttyS10", PortType8250, "serial8250", ""},
+		{"/dev/ttyUSB0", PortTypeUSBSerial, "ftdi_sio", "1-2"},
+		{"/dev/ttymxc1", PortTypeIMX, "", ""},
+	}
+	if len(info.Devices) != len(expected) {
+		t.Fatalf("expected %d devices, got %d", len(expected), len(info.Devices))
+	}
+	for i, exp := range expected {
+		dev := info.Devices[i]
+		if dev.Address != exp.address {
+			t.Errorf("expected address %s, got %s", exp.address, dev.Address)
+		}
+		if dev.Type != exp.typ {
+			t.Errorf("%s: expected type %s, got %s", dev.Address, exp.typ, dev.Type)
+		}
+		if dev.Driver != exp.driver {
+			t.Errorf("%s: expected driver %q, got %q", dev.Address, exp.driver, dev.Driver)
+		}
+		usb := ""
+		if dev.Parent.USB != nil {
+			usb = dev.Parent.USB.String()
+		}
+		if usb != exp.usb {
+			t.Errorf("%s: expected USB parent %q, got %q", dev.Address, exp.usb, usb)
+		}
+		if exp.usb != "" && (dev.Parent.PCI == nil || dev.Parent.PCI.String() != "0000:00:14.0") {
+			t.Errorf("%s: expected PCI parent 0000:00:14.0, got %+v", dev.Address, dev.Parent.PCI)
+		}
+	}
+
+	// the COM port numbered by firmware
+	if s3 := info.Devices[3]; s3.Name != "COM3" || s3.NameSource != naming.SourceACPIUID {
+		t.Errorf("expected ttyS3 to be COM3 from acpi-uid, got %s from %s", s3.Name, s3.NameSource)
+	}
+
+	setNames(info.Devices, &naming.Policy{Sources: []naming.Source{naming.SourceLocation}})
+	expectedNames := []string{
+		"pci-0000:00:14.0-usb-3:1.0",
+		"platform-9000000.pl011",
+		"platform-serial8250-0",
+		"pnp-00:01",
+		"platform-serial8250-1",
+		"pci-0000:00:14.0-usb-2:1.0",
+		"platform-30890000.serial",
+	}
+	for i, name := range expectedNames {
+		if info.Devices[i].Name != name || info.Devices[i].NameSource != naming.SourceLocation {
+			t.Errorf("%s: expected name %s, got %s from %s", info.Devices[i].Address, name, info.Devices[i].Name, info.Devices[i].NameSource)
+		}
+	}
+}
+
+func TestUART(t *testing.T) {
+	root, err := os.MkdirTemp("", "ghw-serial-test-")
+	if err != nil {
+		t.Fatalf("could not create temp directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		"sys/class/tty/ttyS0/type":           "4\n",
+		"sys/class/tty/ttyS0/io_type":        "0\n",
+		"sys/class/tty/ttyS0/port":           "0x3F8\n",
+		"sys/class/tty/ttyS0/uartclk":        "1843200\n",
+		"sys/class/tty/ttyS0/xmit_fifo_size": "16\n",
+		"sys/class/tty/ttyS0/flags":          "0x10000040\n",
+		"sys/class/tty/ttyS0/console":        "N\n",
+		"sys/class/tty/ttyS1/type":           "0\n",
+		"sys/class/tty/ttyS1/io_type":        "0\n",
+		"sys/class/tty/ttyS1/port":           "0x2F8\n",
+		"sys/class/tty/ttyS4/type":           "24\n",
+		"sys/class/tty/ttyS4/io_type":        "3\n",
+		"sys/class/tty/ttyS4/port":           "0x0\n",
+		"sys/class/tty/ttyS4/iomem_base":     "0xFE001000\n",
+		"sys/class/tty/ttyS5/type":           "99\n",
+		"sys/class/tty/ttyAMA0/type":         "32\n",
+		"sys/class/tty/ttyAMA0/io_type":      "2\n",
+		"sys/class/tty/ttyAMA0/port":         "0x0\n",
+		"sys/class/tty/ttyAMA0/uartclk":      "24000000\n",
+		"sys/class/tty/ttyAMA0/device/of_node/linux,rs485-enabled-at-boot-time": "",
+		"sys/class/tty/ttyAMA0/device/of_node/rs485-rts-delay":                  "\x00\x00\x00\x01\x00\x00\x00\x02",
+		"proc/tty/drivers": "/dev/tty             /dev/tty        5       0 system:/dev/tty\n" +
+			"serial               /dev/ttyS       4 64-67 serial\n" +
+			"ttyAMA               /dev/ttyAMA   204 64-77 serial\n",
+		"proc/tty/driver/serial": "serinfo:1.0 driver revision:\n" +
+			"0: uart:16550A port:000003F8 irq:4 tx:0 rx:0 RTS|DTR\n" +
+			"1: uart:unknown port:000002F8 irq:3\n",
+		"proc/tty/driver/ttyAMA": "serinfo:1.0 driver revision:\n" +
+			"0: uart:PL011 rev2 mmio:0x09000000 irq:38 tx:0 rx:0\n",
+		"proc/consoles": "ttyAMA0              -W- (EC  p a)  204:64\n",
+		"proc/cmdline":  "root=/dev/sda1 console=uart8250,io,0x2f8,115200n8 console=uart,mmio32,0xfe001000 -- console=ttyS0\n",
+	}
+	for name, content := range files {
+		fp := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+			t.Fatalf("could not create %s directory: %v", name, err)
+		}
+		if err := os.WriteFile(fp, []byte(content), 0644); err != nil {
+			t.Fatalf("could not write %s: %v", name, err)
+		}
+	}
+	for _, tty := range []string{"ttyS0", "ttyS1", "ttyS4", "ttyS5"} {
+		if err := os.MkdirAll(filepath.Join(root, "sys", "class", "tty", tty, "device"), 0755); err != nil {
+			t.Fatalf("could not create %s device directory: %v", tty, err)
+		}
+	}
+
+	info, err := New(option.WithChroot(root))
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+	if len(info.Devices) != 5 {
+		t.Fatalf("expected 5 devices, got %d", len(info.Devices))
+	}
+
+	ama0, s0, s1, s4, s5 := info.Devices[0], info.Devices[1], info.Devices[2], info.Devices[3], info.Devices[4]
+	if ama0.UART != "PL011 rev2" || ama0.UARTClock != 24000000 || !ama0.Console {
+		t.Errorf("unexpected ttyAMA0 %v", ama0)
+	}
+	expectedRS485 := &RS485{EnabledAtBoot: true, RTSDelayBeforeSend: 1, RTSDelayAfterSend: 2}
+	if !reflect.DeepEqual(ama0.RS485, expectedRS485) {
+		t.Errorf("expected RS-485 %+v, got %+v", expectedRS485, ama0.RS485)
+	}
+	if s0.UART != "16550A" || s0.UARTClock != 1843200 || s0.FIFOSize != 16 || s0.Flags != "0x10000040" {
+		t.Errorf("unexpected ttyS0 %v", s0)
+	}
+	// console=ttyS0 comes after "--", it is for init
+	if s0.Console || s0.RS485 != nil {
+		t.Errorf("expected ttyS0 not to be a console nor RS-485")
+	}
+	if s1.UART != "" || !s1.Console {
+		t.Errorf("expected ttyS1 to be a console with no UART, got %v", s1)
+	}
+	// an Exar XR17V35x, and a type unknown to ghw
+	if s4.UART != "XR17V35X" || s5.UART != "PORT_99" {
+		t.Errorf("expected UARTs XR17V35X and PORT_99, got %q and %q", s4.UART, s5.UART)
+	}
+	// console=uart,mmio32 matches by MMIO address
+	if !s4.Console || s5.Console {
+		t.Errorf("expected ttyS4 only to be a console, got %v and %v", s4.Console, s5.Console)
+	}
+}
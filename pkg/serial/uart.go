@@ -0,0 +1,72 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package serial
+
+import "fmt"
+
+// RS485 is the RS-485 configuration of a port, from the rs485 properties of
+// its device tree node.
+type RS485 struct {
+	// linux,rs485-enabled-at-boot-time: the port starts in RS-485 mode
+	EnabledAtBoot bool `json:"enabled_at_boot"`
+	// rs485-rts-active-low: RTS is driven low while sending
+	RTSActiveLow bool `json:"rts_active_low"`
+	// rs485-rx-during-tx: the receiver stays enabled while sending
+	RXDuringTX bool `json:"rx_during_tx"`
+	// rs485-rts-delay: the delays in ms between asserting RTS and sending,
+	// and between sending and deasserting RTS
+	RTSDelayBeforeSend uint32 `json:"rts_delay_before_send_ms"`
+	RTSDelayAfterSend  uint32 `json:"rts_delay_after_send_ms"`
+	// rs485-term-gpios: a GPIO switches the bus termination
+	TerminationGPIO bool `json:"termination_gpio"`
+}
+
+// uartTypes are the names of the 8250 UART models, as the kernel names them
+// in uart_config[], keyed by their PORT_* number from
+// include/uapi/linux/serial_core.h as found in the type sysfs file
+var uartTypes = map[uint64]string{
+	0:  "", // PORT_UNKNOWN
+	1:  "8250",
+	2:  "16450",
+	3:  "16550",
+	4:  "16550A",
+	5:  "Cirrus",
+	6:  "ST16650",
+	7:  "ST16650V2",
+	8:  "TI16750",
+	9:  "Startech",
+	10: "16C950/954",
+	11: "ST16654",
+	12: "XR16850",
+	13: "RSA",
+	14: "NS16550A",
+	15: "XScale",
+	17: "OCTEON",
+	18: "AR7",
+	19: "U6_16550A",
+	20: "Tegra",
+	21: "XR17D15X",
+	22: "LPC3220",
+	23: "CIR port",
+	24: "XR17V35X",
+	25: "TruManage",
+	26: "Altera 16550 FIFO32",
+	27: "Altera 16550 FIFO64",
+	28: "Altera 16550 FIFO128",
+	29: "Palmchip BK-3103",
+	30: "16550A_FSL64",
+}
+
+// uartType returns the name of the 8250 UART model with the given PORT_*
+// number, empty if no UART was detected, or "PORT_<n>" if the model is not
+// known to ghw
+func uartType(portType uint64) string {
+	if name, ok := uartTypes[portType]; ok {
+		return name
+	}
+	return fmt.Sprintf("PORT_%d", portType)
+}
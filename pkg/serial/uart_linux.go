@@ -0,0 +1,121 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package serial
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/zededa/ghw/pkg/linuxpath"
+)
+
+// procUARTs returns the UART models the serial drivers report in
+// /proc/tty/driver, keyed by tty, e.g. "16550A" for "ttyS0". These files are
+// only readable by root.
+func procUARTs(paths *linuxpath.Paths) map[string]string {
+	uarts := map[string]string{}
+	f, err := os.Open(paths.ProcTtyDrivers)
+	if err != nil {
+		return uarts
+	}
+	defer f.Close()
+	for driver, prefix := range parseTtyDrivers(f) {
+		df, err := os.Open(filepath.Join(paths.ProcTtyDriver, driver))
+		if err != nil {
+			continue
+		}
+		for line, uart := range parseTtyDriver(df) {
+			uarts[prefix+strconv.Itoa(line)] = uart
+		}
+		df.Close()
+	}
+	return uarts
+}
+
+// parseTtyDrivers parses /proc/tty/drivers, returning the tty name prefix of
+// each serial driver, keyed by driver name, e.g. "ttyS" for "serial":
+//
+//	serial               /dev/ttyS       4 64-111 serial
+func parseTtyDrivers(r io.Reader) map[string]string {
+	prefixes := map[string]string{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 5 || fields[4] != "serial" {
+			continue
+		}
+		prefixes[fields[0]] = strings.TrimPrefix(fields[1], "/dev/")
+	}
+	return prefixes
+}
+
+// parseTtyDriver parses a /proc/tty/driver file, returning the UART model of
+// each line known to the driver:
+//
+//	serinfo:1.0 driver revision:
+//	0: uart:16550A port:000003F8 irq:4 tx:0 rx:0 RTS|DTR
+//	1: uart:unknown port:000002F8 irq:3
+//	0: uart:PL011 rev2 mmio:0x09000000 irq:38 tx:0 rx:0
+func parseTtyDriver(r io.Reader) map[int]string {
+	uarts := map[int]string{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line, info, found := strings.Cut(sc.Text(), ":")
+		if !found {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(line))
+		if err != nil {
+			continue
+		}
+		fields := strings.Fields(info)
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "uart:") {
+			continue
+		}
+		// the model ends with the next key:value field
+		model := []string{strings.TrimPrefix(fields[0], "uart:")}
+		for _, field := range fields[1:] {
+			if strings.Contains(field, ":") {
+				break
+			}
+			model = append(model, field)
+		}
+		uart := strings.Join(model, " ")
+		if uart == "unknown" {
+			uart = ""
+		}
+		uarts[n] = uart
+	}
+	return uarts
+}
+
+// getRS485 returns the RS-485 configuration in the device tree node of the
+// given UART device, nil if none
+func getRS485(devSys string) *RS485 {
+	node := filepath.Join(devSys, "of_node")
+	rs485 := &RS485{
+		EnabledAtBoot:   statOK(filepath.Join(node, "linux,rs485-enabled-at-boot-time")),
+		RTSActiveLow:    statOK(filepath.Join(node, "rs485-rts-active-low")),
+		RXDuringTX:      statOK(filepath.Join(node, "rs485-rx-during-tx")),
+		TerminationGPIO: statOK(filepath.Join(node, "rs485-term-gpios")),
+	}
+	// two big-endian cells: the delays before and after sending
+	delay, err := os.ReadFile(filepath.Join(node, "rs485-rts-delay"))
+	hasDelay := err == nil && len(delay) == 8
+	if hasDelay {
+		rs485.RTSDelayBeforeSend = binary.BigEndian.Uint32(delay[0:4])
+		rs485.RTSDelayAfterSend = binary.BigEndian.Uint32(delay[4:8])
+	}
+	if !hasDelay && !rs485.EnabledAtBoot && !rs485.RTSActiveLow && !rs485.RXDuringTX && !rs485.TerminationGPIO {
+		return nil
+	}
+	return rs485
+}
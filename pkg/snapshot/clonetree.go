@@ -43,6 +43,7 @@ func ExpectedCloneContent() []string {
 	fileSpecs = append(fileSpecs, ExpectedCloneUSBContent()...)
 	fileSpecs = append(fileSpecs, ExpectedClonePCIContent()...)
 	fileSpecs = append(fileSpecs, ExpectedCloneGPUContent()...)
+	fileSpecs = append(fileSpecs, ExpectedCloneThunderboltContent()...)
 	return fileSpecs
 }
 
@@ -33,6 +33,9 @@ func ExpectedCloneStaticContent() []string {
 		"/proc/cpuinfo",
 		"/proc/meminfo",
 		"/proc/self/mounts",
+		"/proc/interrupts",
+		"/proc/irq/*/smp_affinity*",
+		"/proc/irq/*/effective_affinity*",
 		"/sys/devices/system/cpu/cpu*/cache/index*/*",
 		"/sys/devices/system/cpu/cpu*/topology/*",
 		"/sys/devices/system/memory/block_size_bytes",
@@ -27,6 +27,9 @@ const (
 func ExpectedClonePCIContent() []string {
 	fileSpecs := []string{
 		"/sys/bus/pci/drivers/*",
+		"/sys/bus/pci/slots/*/*",
+		// SMBIOS System Slot records
+		"/sys/firmware/dmi/entries/9-*/raw",
 	}
 	pciRoots := []string{
 		sysBusPCIDir,
@@ -69,6 +72,24 @@ func scanPCIDeviceRoot(root string) (fileSpecs []string, pciRoots []string) {
 		"numa_node",
 		"revision",
 		"vendor",
+		"sriov_*",
+		"virtfn*",
+		"physfn",
+		"current_link_speed",
+		"current_link_width",
+		"max_link_speed",
+		"max_link_width",
+		"resource",
+		"config",
+		"reset_method",
+		"aer_dev_*",
+		"aer_rootport_total_*",
+		"msi_irqs/*",
+		"power_state",
+		"power/runtime_status",
+		"power/control",
+		"d3cold_allowed",
+		"enable",
 	}
 	entries, err := os.ReadDir(root)
 	if err != nil {
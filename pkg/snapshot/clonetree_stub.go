@@ -32,3 +32,7 @@ func ExpectedClonePCIContent() []string {
 func ExpectedCloneUSBContent() []string {
 	return []string{}
 }
+
+func ExpectedCloneThunderboltContent() []string {
+	return []string{}
+}
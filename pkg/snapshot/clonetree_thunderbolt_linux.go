@@ -0,0 +1,55 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ExpectedCloneThunderboltContent returns a slice of glob patterns pertaining
+// to the Thunderbolt domains and routers
+func ExpectedCloneThunderboltContent() []string {
+	const sysBusThunderbolt = "/sys/bus/thunderbolt/devices/"
+
+	entries, err := os.ReadDir(sysBusThunderbolt)
+	if err != nil {
+		return []string{}
+	}
+
+	paths := []string{sysBusThunderbolt}
+	for _, entry := range entries {
+		link := filepath.Join(sysBusThunderbolt, entry.Name())
+		paths = append(paths, link)
+
+		fullDir, err := filepath.EvalSymlinks(link)
+		if err != nil {
+			continue
+		}
+		for _, fileName := range []string{
+			"security",
+			"iommu_dma_protection",
+			"vendor",
+			"vendor_name",
+			"device",
+			"device_name",
+			"unique_id",
+			"generation",
+			"authorized",
+			"nvm_version",
+			"rx_speed",
+			"rx_lanes",
+			"tx_speed",
+			"tx_lanes",
+			// device links of the host controller to its PCIe root ports
+			"../consumer:pci:*",
+		} {
+			paths = append(paths, filepath.Join(fullDir, fileName))
+		}
+	}
+	return paths
+}
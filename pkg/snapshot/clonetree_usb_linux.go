@@ -16,6 +16,10 @@ func ExpectedCloneUSBContent() []string {
 	const sysBusUSB = "/sys/bus/usb/devices/"
 
 	paths := []string{sysBusUSB}
+	// the kernel devices USB drivers create, see usb.ClassDevice
+	for _, class := range []string{"tty", "net", "block", "input", "video4linux", "sound", "hidraw"} {
+		paths = append(paths, filepath.Join("/sys/class", class, "*"))
+	}
 	usbDevicesDirs, err := os.ReadDir(sysBusUSB)
 	if err != nil {
 		return []string{}
@@ -44,6 +48,25 @@ func ExpectedCloneUSBContent() []string {
 			"bDeviceClass",
 			"bDeviceSubClass",
 			"bDeviceProtocol",
+			"speed",
+			"version",
+			"bos_descriptors",
+			"bMaxPower",
+			"bNumConfigurations",
+			"manufacturer",
+			"serial",
+			"authorized",
+			"removable",
+			"bInterfaceNumber",
+			"bAlternateSetting",
+			"bInterfaceClass",
+			"bInterfaceSubClass",
+			"bInterfaceProtocol",
+			"bNumEndpoints",
+			"driver",
+			"*-port*/connect_type",
+			"*-port*/peer",
+			"*-port*/physical_location/*",
 		} {
 			paths = append(paths, filepath.Join(fullDir, fileName))
 		}
@@ -0,0 +1,204 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package thunderbolt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zededa/ghw/pkg/marshal"
+	"github.com/zededa/ghw/pkg/option"
+)
+
+// SecurityLevel is the policy a Thunderbolt domain applies before tunneling
+// PCIe to a newly connected device.
+type SecurityLevel string
+
+const (
+	// SecurityLevelNone lets all devices connect automatically
+	SecurityLevelNone SecurityLevel = "none"
+	// SecurityLevelUser requires the user to authorize each device
+	SecurityLevelUser SecurityLevel = "user"
+	// SecurityLevelSecure requires the user to authorize each device, and
+	// challenges known devices with a key
+	SecurityLevelSecure SecurityLevel = "secure"
+	// SecurityLevelDPOnly only tunnels DisplayPort, never PCIe
+	SecurityLevelDPOnly SecurityLevel = "dponly"
+	// SecurityLevelUSBOnly only tunnels USB and DisplayPort, never PCIe
+	SecurityLevelUSBOnly SecurityLevel = "usbonly"
+	// SecurityLevelNoPCIe tunnels everything but PCIe
+	SecurityLevelNoPCIe SecurityLevel = "nopcie"
+)
+
+// AllowsPCIe returns true if PCIe can be tunneled at this security level.
+func (s SecurityLevel) AllowsPCIe() bool {
+	switch s {
+	case SecurityLevelDPOnly, SecurityLevelUSBOnly, SecurityLevelNoPCIe:
+		return false
+	}
+	return true
+}
+
+// Authorization is the authorization state of a Thunderbolt device.
+type Authorization string
+
+const (
+	// AuthorizationNone indicates the device is not authorized: no PCIe
+	// tunnel is established to it
+	AuthorizationNone Authorization = "unauthorized"
+	// AuthorizationAuthorized indicates the device is authorized
+	AuthorizationAuthorized Authorization = "authorized"
+	// AuthorizationSecure indicates the device is authorized and its key
+	// was verified
+	AuthorizationSecure Authorization = "secure"
+)
+
+// authorizations maps the values of the sysfs authorized file
+var authorizations = map[string]Authorization{
+	"0": AuthorizationNone,
+	"1": AuthorizationAuthorized,
+	"2": AuthorizationSecure,
+}
+
+// Link describes the link of a router to its upstream router, in one
+// direction
+type Link struct {
+	// The speed of each lane in Gb/s, e.g. 20
+	SpeedGbps float64 `json:"speed_gbps"`
+	Lanes     int     `json:"lanes"`
+}
+
+// Bandwidth returns the total bandwidth of the link in Gb/s.
+func (l *Link) Bandwidth() float64 {
+	return l.SpeedGbps * float64(l.Lanes)
+}
+
+// Router describes a Thunderbolt or USB4 router: the host router of a domain
+// or a connected device, e.g. a dock or an external PCIe enclosure.
+type Router struct {
+	// The sysfs name of the router, <domain>-<route>, e.g. "0-1"
+	Name string `json:"name"`
+	// The route string of the router, in hexadecimal; "0" for the host
+	// router
+	Route string `json:"route"`
+	// The name of the upstream router, empty for the host router
+	Parent     string `json:"parent,omitempty"`
+	VendorID   string `json:"vendor_id"`
+	VendorName string `json:"vendor_name"`
+	DeviceID   string `json:"device_id"`
+	DeviceName string `json:"device_name"`
+	UniqueID   string `json:"unique_id,omitempty"`
+	// 1 to 3 for Thunderbolt 1 to 3, 4 for USB4 and Thunderbolt 4; 0 if
+	// unknown
+	Generation int `json:"generation"`
+	// Empty for the host router, which needs no authorization
+	Authorization Authorization `json:"authorization,omitempty"`
+	// Receive and transmit links, nil for the host router or if unknown
+	Rx         *Link  `json:"rx,omitempty"`
+	Tx         *Link  `json:"tx,omitempty"`
+	NVMVersion string `json:"nvm_version,omitempty"`
+}
+
+// IsHost returns true if the router is the host router of its domain.
+func (r *Router) IsHost() bool {
+	return r.Route == "0"
+}
+
+func (r *Router) String() string {
+	str := fmt.Sprintf("%s %s %s", r.Name, r.VendorName, r.DeviceName)
+	if r.Generation > 0 {
+		str += fmt.Sprintf(" gen=%d", r.Generation)
+	}
+	if !r.IsHost() {
+		str += " " + string(r.Authorization)
+	}
+	if r.Rx != nil {
+		str += fmt.Sprintf(" link=%gGb/s x%d", r.Rx.SpeedGbps, r.Rx.Lanes)
+	}
+	return str
+}
+
+// Tunnel describes a PCIe tunnel of a domain: a PCIe port of the host
+// controller, and the PCI devices reached through it
+type Tunnel struct {
+	// The PCI address of the PCIe root or downstream port
+	Port string `json:"port"`
+	// The PCI addresses of the devices behind the port, empty if no device
+	// is connected
+	Devices []string `json:"devices"`
+}
+
+// Domain describes a Thunderbolt or USB4 domain: a host controller and the
+// routers connected to it.
+type Domain struct {
+	// The sysfs name of the domain, e.g. "domain0"
+	Name     string        `json:"name"`
+	Security SecurityLevel `json:"security"`
+	// True if the IOMMU protects the host against DMA from devices, which
+	// makes authorization less critical
+	IOMMUDMAProtection bool `json:"iommu_dma_protection"`
+	// The PCI address of the host controller (NHI), empty if not a PCI
+	// device
+	Controller string `json:"controller,omitempty"`
+	// The routers of the domain, host router first
+	Routers []*Router `json:"routers"`
+	// The PCIe tunnels of the domain
+	Tunnels []*Tunnel `json:"tunnels"`
+}
+
+func (d *Domain) String() string {
+	return fmt.Sprintf(
+		"%s security=%s controller=%s (%d routers)",
+		d.Name, d.Security, d.Controller, len(d.Routers),
+	)
+}
+
+// Info describes the Thunderbolt and USB4 domains of the host system.
+type Info struct {
+	Domains []*Domain `json:"domains"`
+}
+
+func (i *Info) String() string {
+	return fmt.Sprintf("Thunderbolt (%d domains)", len(i.Domains))
+}
+
+// TunnelOf returns the domain and the PCIe tunnel through which the PCI
+// device at the given address, e.g. "0000:3b:00.0", is attached, or nils if
+// the device is not attached over Thunderbolt.
+func (i *Info) TunnelOf(pciAddress string) (*Domain, *Tunnel) {
+	pciAddress = strings.ToLower(pciAddress)
+	for _, domain := range i.Domains {
+		for _, tunnel := range domain.Tunnels {
+			for _, addr := range tunnel.Devices {
+				if addr == pciAddress {
+					return domain, tunnel
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+func New(opts ...option.Option) (*Info, error) {
+	merged := option.FromEnv()
+	for _, opt := range opts {
+		opt(merged)
+	}
+	info := &Info{Domains: []*Domain{}}
+	if err := info.load(merged); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (i *Info) JSONString(indent bool) string {
+	return marshal.SafeJSON(i, indent)
+}
+
+func (i *Info) YAMLString() string {
+	return marshal.SafeYAML(i)
+}
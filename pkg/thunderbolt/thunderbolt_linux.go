@@ -0,0 +1,230 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package thunderbolt
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zededa/ghw/pkg/linuxpath"
+	"github.com/zededa/ghw/pkg/option"
+	pciAddress "github.com/zededa/ghw/pkg/pci/address"
+)
+
+var (
+	domainNameRe = regexp.MustCompile(`^domain(\d+)$`)
+	// routers are named <domain>-<route>; retimers and XDomain links to
+	// other hosts have other names
+	routerNameRe = regexp.MustCompile(`^(\d+)-([0-9a-f]+)$`)
+)
+
+func (i *Info) load(opts *option.Options) error {
+	paths := linuxpath.New(opts)
+	entries, err := os.ReadDir(paths.SysBusThunderboltDevices)
+	if err != nil {
+		return nil // Return empty if the host has no Thunderbolt support
+	}
+
+	domains := map[string]*Domain{}
+	routers := map[string][]*Router{}
+	for _, entry := range entries {
+		name := entry.Name()
+		devDir, err := filepath.EvalSymlinks(filepath.Join(paths.SysBusThunderboltDevices, name))
+		if err != nil {
+			continue
+		}
+		if m := domainNameRe.FindStringSubmatch(name); m != nil {
+			domain := getDomain(paths, devDir)
+			domains[m[1]] = domain
+			i.Domains = append(i.Domains, domain)
+		} else if m := routerNameRe.FindStringSubmatch(name); m != nil {
+			routers[m[1]] = append(routers[m[1]], getRouter(devDir, m[2]))
+		}
+	}
+
+	for id, domain := range domains {
+		domain.Routers = append(domain.Routers, routers[id]...)
+		sort.Slice(domain.Routers, func(i, j int) bool {
+			return routeLess(domain.Routers[i].Route, domain.Routers[j].Route)
+		})
+	}
+	sort.Slice(i.Domains, func(x, y int) bool {
+		return domainLess(i.Domains[x].Name, i.Domains[y].Name)
+	})
+	return nil
+}
+
+// routeLess orders routes by depth first: the route string holds one byte
+// per hop, the first hop being the least significant one
+func routeLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+func domainLess(a, b string) bool {
+	x, _ := strconv.Atoi(strings.TrimPrefix(a, "domain"))
+	y, _ := strconv.Atoi(strings.TrimPrefix(b, "domain"))
+	return x < y
+}
+
+func getDomain(paths *linuxpath.Paths, domainDir string) *Domain {
+	domain := &Domain{
+		Name:               filepath.Base(domainDir),
+		Security:           SecurityLevel(slurp(filepath.Join(domainDir, "security"))),
+		IOMMUDMAProtection: slurp(filepath.Join(domainDir, "iommu_dma_protection")) == "1",
+		Routers:            []*Router{},
+		Tunnels:            []*Tunnel{},
+	}
+	nhiDir := filepath.Dir(domainDir)
+	if pciAddress.FromString(filepath.Base(nhiDir)) == nil {
+		return domain
+	}
+	domain.Controller = filepath.Base(nhiDir)
+	for _, portDir := range getTunnelPorts(paths, nhiDir) {
+		domain.Tunnels = append(domain.Tunnels, &Tunnel{
+			Port:    filepath.Base(portDir),
+			Devices: pciDevicesBelow(portDir),
+		})
+	}
+	return domain
+}
+
+// getTunnelPorts returns the sysfs directories of the PCIe ports the host
+// controller whose NHI is described by the given directory tunnels PCIe
+// through.
+//
+// Integrated controllers tunnel through dedicated PCIe root ports, which the
+// kernel links to the NHI with device links, showing up as
+// consumer:pci:<address> entries. Discrete controllers embed a PCIe switch
+// whose downstream ports are the siblings of the one of the NHI, along with
+// the one of the USB controller.
+func getTunnelPorts(paths *linuxpath.Paths, nhiDir string) []string {
+	portDirs := []string{}
+	consumers, _ := filepath.Glob(filepath.Join(nhiDir, "consumer:pci:*"))
+	for _, consumer := range consumers {
+		addr := strings.TrimPrefix(filepath.Base(consumer), "consumer:pci:")
+		portDir, err := filepath.EvalSymlinks(filepath.Join(paths.SysBusPciDevices, addr))
+		if err == nil {
+			portDirs = append(portDirs, portDir)
+		}
+	}
+	if len(consumers) > 0 {
+		return portDirs
+	}
+
+	nhiPortDir := filepath.Dir(nhiDir)
+	if pciAddress.FromString(filepath.Base(nhiPortDir)) == nil {
+		return portDirs
+	}
+	switchDir := filepath.Dir(nhiPortDir)
+	if pciAddress.FromString(filepath.Base(switchDir)) == nil {
+		return portDirs
+	}
+	for _, sibling := range pciChildDirs(switchDir) {
+		if sibling == nhiPortDir || hasUSBController(sibling) {
+			continue
+		}
+		portDirs = append(portDirs, sibling)
+	}
+	return portDirs
+}
+
+// pciChildDirs returns the sysfs directories of the PCI devices directly
+// below the given one
+func pciChildDirs(devDir string) []string {
+	dirs := []string{}
+	entries, err := os.ReadDir(devDir)
+	if err != nil {
+		return dirs
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && pciAddress.FromString(entry.Name()) != nil &&
+			strings.Count(entry.Name(), ":") == 2 {
+			dirs = append(dirs, filepath.Join(devDir, entry.Name()))
+		}
+	}
+	return dirs
+}
+
+// hasUSBController returns true if a USB controller (PCI class 0c03) is
+// directly below the given port
+func hasUSBController(portDir string) bool {
+	for _, child := range pciChildDirs(portDir) {
+		if strings.HasPrefix(slurp(filepath.Join(child, "class")), "0x0c03") {
+			return true
+		}
+	}
+	return false
+}
+
+// pciDevicesBelow returns the addresses of all the PCI devices below the
+// given port
+func pciDevicesBelow(portDir string) []string {
+	addrs := []string{}
+	_ = filepath.WalkDir(portDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || path == portDir {
+			return nil
+		}
+		name := d.Name()
+		if pciAddress.FromString(name) != nil && strings.Count(name, ":") == 2 {
+			addrs = append(addrs, name)
+			return nil
+		}
+		// PCI devices are only nested in PCI devices
+		return filepath.SkipDir
+	})
+	sort.Strings(addrs)
+	return addrs
+}
+
+func getRouter(routerDir, route string) *Router {
+	router := &Router{
+		Name:          filepath.Base(routerDir),
+		Route:         route,
+		VendorID:      slurp(filepath.Join(routerDir, "vendor")),
+		VendorName:    slurp(filepath.Join(routerDir, "vendor_name")),
+		DeviceID:      slurp(filepath.Join(routerDir, "device")),
+		DeviceName:    slurp(filepath.Join(routerDir, "device_name")),
+		UniqueID:      slurp(filepath.Join(routerDir, "unique_id")),
+		Authorization: authorizations[slurp(filepath.Join(routerDir, "authorized"))],
+		NVMVersion:    slurp(filepath.Join(routerDir, "nvm_version")),
+		Rx:            getLink(routerDir, "rx"),
+		Tx:            getLink(routerDir, "tx"),
+	}
+	router.Generation, _ = strconv.Atoi(slurp(filepath.Join(routerDir, "generation")))
+	if parent := filepath.Base(filepath.Dir(routerDir)); routerNameRe.MatchString(parent) {
+		router.Parent = parent
+	}
+	return router
+}
+
+// getLink returns the link of the router in the given direction, "rx" or
+// "tx", from the <dir>_speed and <dir>_lanes files, e.g. "20.0 Gb/s" and "2"
+func getLink(routerDir, dir string) *Link {
+	speed, err := strconv.ParseFloat(
+		strings.TrimSuffix(slurp(filepath.Join(routerDir, dir+"_speed")), " Gb/s"), 64,
+	)
+	if err != nil {
+		return nil
+	}
+	lanes, _ := strconv.Atoi(slurp(filepath.Join(routerDir, dir+"_lanes")))
+	return &Link{SpeedGbps: speed, Lanes: lanes}
+}
+
+func slurp(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,157 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package thunderbolt
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/zededa/ghw/pkg/option"
+)
+
+// writeFakeSysfs creates the given files under root, and links the given
+// /sys/bus entries to their directory under /sys/devices
+func writeFakeSysfs(t *testing.T, root string, files map[string]string, links map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		fp := filepath.Join(root, "sys", "devices", name)
+		if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fp, []byte(content+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for link, target := range links {
+		fp := filepath.Join(root, "sys", link)
+		if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Join(root, "sys", "devices", target), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(filepath.Join(root, "sys", "devices", target), fp); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIntegratedController(t *testing.T) {
+	root := t.TempDir()
+	const nhi = "pci0000:00/0000:00:0d.2"
+	writeFakeSysfs(t, root, map[string]string{
+		nhi + "/domain0/security":                                              "user",
+		nhi + "/domain0/iommu_dma_protection":                                  "1",
+		nhi + "/domain0/0-0/vendor":                                            "0x8087",
+		nhi + "/domain0/0-0/vendor_name":                                       "Intel",
+		nhi + "/domain0/0-0/device":                                            "0x9a1b",
+		nhi + "/domain0/0-0/device_name":                                       "Tiger Lake",
+		nhi + "/domain0/0-0/generation":                                        "4",
+		nhi + "/domain0/0-0/0-1/vendor":                                        "0x108",
+		nhi + "/domain0/0-0/0-1/vendor_name":                                   "Lenovo",
+		nhi + "/domain0/0-0/0-1/device":                                        "0x2031",
+		nhi + "/domain0/0-0/0-1/device_name":                                   "ThinkPad Thunderbolt 4 Dock",
+		nhi + "/domain0/0-0/0-1/generation":                                    "4",
+		nhi + "/domain0/0-0/0-1/authorized":                                    "1",
+		nhi + "/domain0/0-0/0-1/rx_speed":                                      "20.0 Gb/s",
+		nhi + "/domain0/0-0/0-1/rx_lanes":                                      "2",
+		nhi + "/domain0/0-0/0-1/tx_speed":                                      "20.0 Gb/s",
+		nhi + "/domain0/0-0/0-1/tx_lanes":                                      "2",
+		"pci0000:00/0000:00:07.0/0000:20:00.0/0000:21:01.0/0000:22:00.0/class": "0x020000",
+		"pci0000:00/0000:00:07.1/class":                                        "0x060400",
+	}, map[string]string{
+		"bus/thunderbolt/devices/domain0":               nhi + "/domain0",
+		"bus/thunderbolt/devices/0-0":                   nhi + "/domain0/0-0",
+		"bus/thunderbolt/devices/0-1":                   nhi + "/domain0/0-0/0-1",
+		"bus/pci/devices/0000:00:07.0":                  "pci0000:00/0000:00:07.0",
+		"bus/pci/devices/0000:00:07.1":                  "pci0000:00/0000:00:07.1",
+		"devices/" + nhi + "/consumer:pci:0000:00:07.0": "virtual/devlink/pci:0000:00:0d.2--pci:0000:00:07.0",
+		"devices/" + nhi + "/consumer:pci:0000:00:07.1": "virtual/devlink/pci:0000:00:0d.2--pci:0000:00:07.1",
+	})
+
+	info, err := New(option.WithChroot(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Domains) != 1 {
+		t.Fatalf("expected 1 domain, got %d", len(info.Domains))
+	}
+	domain := info.Domains[0]
+	if domain.Security != SecurityLevelUser || !domain.IOMMUDMAProtection || domain.Controller != "0000:00:0d.2" {
+		t.Fatalf("unexpected domain %+v", domain)
+	}
+	if len(domain.Routers) != 2 || !domain.Routers[0].IsHost() {
+		t.Fatalf("expected the host router then the dock, got %v", domain.Routers)
+	}
+	dock := domain.Routers[1]
+	expected := &Router{
+		Name:          "0-1",
+		Route:         "1",
+		Parent:        "0-0",
+		VendorID:      "0x108",
+		VendorName:    "Lenovo",
+		DeviceID:      "0x2031",
+		DeviceName:    "ThinkPad Thunderbolt 4 Dock",
+		Generation:    4,
+		Authorization: AuthorizationAuthorized,
+		Rx:            &Link{SpeedGbps: 20, Lanes: 2},
+		Tx:            &Link{SpeedGbps: 20, Lanes: 2},
+	}
+	if !reflect.DeepEqual(dock, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, dock)
+	}
+	if dock.Rx.Bandwidth() != 40 {
+		t.Fatalf("expected 40 Gb/s, got %v", dock.Rx.Bandwidth())
+	}
+
+	expectedTunnels := []*Tunnel{
+		{Port: "0000:00:07.0", Devices: []string{"0000:20:00.0", "0000:21:01.0", "0000:22:00.0"}},
+		{Port: "0000:00:07.1", Devices: []string{}},
+	}
+	if !reflect.DeepEqual(domain.Tunnels, expectedTunnels) {
+		t.Fatalf("expected tunnels %+v, got %+v", expectedTunnels, domain.Tunnels)
+	}
+	if d, tunnel := info.TunnelOf("0000:22:00.0"); d != domain || tunnel.Port != "0000:00:07.0" {
+		t.Fatalf("expected 0000:22:00.0 to be tunneled through 0000:00:07.0")
+	}
+	if d, _ := info.TunnelOf("0000:00:1f.6"); d != nil {
+		t.Fatalf("expected 0000:00:1f.6 not to be tunneled")
+	}
+}
+
+func TestDiscreteController(t *testing.T) {
+	root := t.TempDir()
+	// Titan Ridge: switch upstream port 03:00.0, NHI behind 04:00.0, PCIe
+	// tunnels behind 04:01.0 and 04:02.0, USB controller behind 04:04.0
+	const sw = "pci0000:00/0000:00:1c.4/0000:03:00.0"
+	writeFakeSysfs(t, root, map[string]string{
+		sw + "/0000:04:00.0/0000:05:00.0/domain0/security": "none",
+		sw + "/0000:04:01.0/0000:06:00.0/class":            "0x040000",
+		sw + "/0000:04:02.0/class":                         "0x060400",
+		sw + "/0000:04:04.0/0000:39:00.0/class":            "0x0c0330",
+	}, map[string]string{
+		"bus/thunderbolt/devices/domain0": sw + "/0000:04:00.0/0000:05:00.0/domain0",
+	})
+
+	info, err := New(option.WithChroot(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	domain := info.Domains[0]
+	expectedTunnels := []*Tunnel{
+		{Port: "0000:04:01.0", Devices: []string{"0000:06:00.0"}},
+		{Port: "0000:04:02.0", Devices: []string{}},
+	}
+	if !reflect.DeepEqual(domain.Tunnels, expectedTunnels) {
+		t.Fatalf("expected tunnels %+v, got %+v", expectedTunnels, domain.Tunnels)
+	}
+	if domain.Security.AllowsPCIe() != true {
+		t.Fatalf("expected security level none to allow PCIe")
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package thunderbolt
+
+import (
+	"github.com/zededa/ghw/pkg/option"
+)
+
+func (i *Info) load(opts *option.Options) error {
+	return nil
+}
@@ -1,6 +1,14 @@
 package address
 
-import "fmt"
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// regexPath matches the USB devices in a sysfs path, e.g.
+// /sys/devices/pci0000:00/0000:00:14.0/usb1/1-2/1-2.3/1-2.3:1.0
+var regexPath = regexp.MustCompile(`\/usb\d+(\/\d+\-[\d\.]+)*(\/(\d+)\-([\d\.]+))`)
 
 type Address struct {
 	Busnum uint16 `json:"bus"`
@@ -10,3 +18,21 @@ type Address struct {
 func (a Address) String() string {
 	return fmt.Sprintf("%d-%s", a.Busnum, a.Port)
 }
+
+// FromPath returns the address of the deepest USB device in a sysfs path,
+// e.g. 1-2.3 for /sys/devices/pci0000:00/0000:00:14.0/usb1/1-2/1-2.3/tty,
+// or nil if the path holds no USB device.
+func FromPath(path string) *Address {
+	matches := regexPath.FindStringSubmatch(path)
+	if len(matches) < 3 {
+		return nil
+	}
+	busnum, err := strconv.ParseUint(matches[len(matches)-2], 10, 16)
+	if err != nil {
+		return nil
+	}
+	return &Address{
+		Busnum: uint16(busnum),
+		Port:   matches[len(matches)-1],
+	}
+}
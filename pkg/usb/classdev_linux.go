@@ -0,0 +1,89 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package usb
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/zededa/ghw/pkg/linuxpath"
+	usbAddress "github.com/zededa/ghw/pkg/usb/address"
+)
+
+// classDeviceClasses lists the /sys/class directories USB drivers commonly
+// create devices in
+var classDeviceClasses = []string{
+	"tty",
+	"net",
+	"block",
+	"input",
+	"video4linux",
+	"sound",
+	"hidraw",
+}
+
+// interfaceNameRe matches the sysfs names of USB interfaces, e.g. 1-2.3:1.0
+var interfaceNameRe = regexp.MustCompile(`^\d+-[\d.]+:\d+\.\d+$`)
+
+// setClassDevices links the devices and interfaces to the kernel devices
+// found beneath them in sysfs
+func setClassDevices(paths *linuxpath.Paths, devs []*Device) {
+	byAddress := make(map[usbAddress.Address]*Device, len(devs))
+	for _, dev := range devs {
+		byAddress[dev.Address] = dev
+	}
+	for _, class := range classDeviceClasses {
+		classDir := filepath.Join(paths.SysClass, class)
+		entries, err := os.ReadDir(classDir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			devPath, err := filepath.EvalSymlinks(filepath.Join(classDir, entry.Name()))
+			if err != nil {
+				continue
+			}
+			// partitions are part of their disk
+			if _, err := os.Stat(filepath.Join(devPath, "partition")); err == nil {
+				continue
+			}
+			addr := usbAddress.FromPath(devPath)
+			if addr == nil {
+				continue
+			}
+			dev, found := byAddress[*addr]
+			if !found {
+				continue
+			}
+			cdev := &ClassDevice{Class: class, Name: entry.Name()}
+			if iface := dev.interfaceOf(devPath); iface != nil {
+				iface.ClassDevices = append(iface.ClassDevices, cdev)
+			} else {
+				dev.ClassDevices = append(dev.ClassDevices, cdev)
+			}
+		}
+	}
+}
+
+// interfaceOf returns the interface of the device the given sysfs path is
+// beneath, or nil if none
+func (d *Device) interfaceOf(devPath string) *Interface {
+	parts := strings.Split(devPath, string(filepath.Separator))
+	for idx := len(parts) - 1; idx >= 0; idx-- {
+		if !interfaceNameRe.MatchString(parts[idx]) {
+			continue
+		}
+		for _, iface := range d.Interfaces {
+			if iface.Name == parts[idx] {
+				return iface
+			}
+		}
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package usb
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SpeedClass is the USB signaling rate a device negotiated with its port.
+type SpeedClass string
+
+const (
+	SpeedClassUnknown SpeedClass = ""
+	// SpeedClassLow is USB 1.x low speed, 1.5 Mbps
+	SpeedClassLow SpeedClass = "low"
+	// SpeedClassFull is USB 1.x full speed, 12 Mbps
+	SpeedClassFull SpeedClass = "full"
+	// SpeedClassHigh is USB 2.0 high speed, 480 Mbps
+	SpeedClassHigh SpeedClass = "high"
+	// SpeedClassSuper is USB 3.x SuperSpeed, 5 Gbps
+	SpeedClassSuper SpeedClass = "super"
+	// SpeedClassSuperPlus is USB 3.x SuperSpeedPlus, 10 Gbps or 20 Gbps
+	SpeedClassSuperPlus SpeedClass = "super+"
+)
+
+// Removable values, telling whether a device can be unplugged by the user,
+// as reported by the hub it is attached to
+const (
+	RemovableRemovable = "removable"
+	RemovableFixed     = "fixed"
+	RemovableUnknown   = "unknown"
+)
+
+// parseSpeed parses the content of a sysfs speed file, e.g. "480" or "1.5",
+// returning the speed in Mbps or 0 if unknown.
+func parseSpeed(speed string) float64 {
+	mbps, err := strconv.ParseFloat(strings.TrimSpace(speed), 64)
+	if err != nil || mbps < 0 {
+		return 0
+	}
+	return mbps
+}
+
+// speedClass returns the class of the given speed in Mbps
+func speedClass(mbps float64) SpeedClass {
+	switch {
+	case mbps <= 0:
+		return SpeedClassUnknown
+	case mbps <= 1.5:
+		return SpeedClassLow
+	case mbps <= 12:
+		return SpeedClassFull
+	case mbps <= 480:
+		return SpeedClassHigh
+	case mbps <= 5000:
+		return SpeedClassSuper
+	default:
+		return SpeedClassSuperPlus
+	}
+}
+
+// parseMaxPower parses the content of a sysfs bMaxPower file, e.g. "500mA",
+// returning the current in mA or 0 if unknown.
+func parseMaxPower(maxPower string) int {
+	ma, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(maxPower), "mA"))
+	if err != nil {
+		return 0
+	}
+	return ma
+}
+
+// versionMajor returns the major number of a USB version such as "3.20", or
+// 0 if unknown
+func versionMajor(version string) int {
+	major, _, _ := strings.Cut(strings.TrimSpace(version), ".")
+	num, err := strconv.Atoi(major)
+	if err != nil {
+		return 0
+	}
+	return num
+}
+
+// BOS descriptor values, from the USB 3.2 specification
+const (
+	bosDescriptorType         = 0x0f
+	deviceCapabilityType      = 0x10
+	capabilitySuperSpeed      = 0x03
+	capabilitySuperSpeedPlus  = 0x0a
+	bosDescriptorHeaderLength = 5
+	deviceCapabilityMinLength = 3
+)
+
+// hasSuperSpeedCapability returns true if the given BOS descriptor, as read
+// from sysfs bos_descriptors, holds a SuperSpeed or SuperSpeedPlus device
+// capability
+func hasSuperSpeedCapability(bos []byte) bool {
+	if len(bos) < bosDescriptorHeaderLength || bos[1] != bosDescriptorType {
+		return false
+	}
+	for i := int(bos[0]); i+deviceCapabilityMinLength <= len(bos); i += int(bos[i]) {
+		if bos[i] < deviceCapabilityMinLength {
+			return false
+		}
+		if bos[i+1] != deviceCapabilityType {
+			continue
+		}
+		switch bos[i+2] {
+		case capabilitySuperSpeed, capabilitySuperSpeedPlus:
+			return true
+		}
+	}
+	return false
+}
+
+// IsSpeedDegraded returns true if the device supports USB 3 yet runs at a
+// USB 2 or lower speed, e.g. a USB 3 storage stick plugged into a USB 2 port
+// or through a bad cable. USB 3 devices running at USB 2 speed often report
+// version 2.10, in which case only their BOS descriptor tells, see
+// SuperSpeedCapable.
+func (d *Device) IsSpeedDegraded() bool {
+	if versionMajor(d.Version) < 3 && !d.SuperSpeedCapable {
+		return false
+	}
+	switch d.SpeedClass {
+	case SpeedClassLow, SpeedClassFull, SpeedClassHigh:
+		return true
+	}
+	return false
+}
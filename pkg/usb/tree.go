@@ -0,0 +1,313 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package usb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zededa/ghw/pkg/marshal"
+	pciAddress "github.com/zededa/ghw/pkg/pci/address"
+	usbAddress "github.com/zededa/ghw/pkg/usb/address"
+)
+
+// ControllerType is the interface of a USB host controller.
+type ControllerType string
+
+const (
+	ControllerTypeUnknown ControllerType = "unknown"
+	// ControllerTypeUHCI is a USB 1.x Universal Host Controller
+	ControllerTypeUHCI ControllerType = "uhci"
+	// ControllerTypeOHCI is a USB 1.x Open Host Controller
+	ControllerTypeOHCI ControllerType = "ohci"
+	// ControllerTypeEHCI is a USB 2.0 Enhanced Host Controller
+	ControllerTypeEHCI ControllerType = "ehci"
+	// ControllerTypeXHCI is a USB 3.x eXtensible Host Controller, which also
+	// drives the USB 2.0 ports
+	ControllerTypeXHCI ControllerType = "xhci"
+)
+
+// controllerTypesByProgIf maps the programming interfaces of the PCI USB
+// controller class (0c03) to controller types
+var controllerTypesByProgIf = map[string]ControllerType{
+	"00": ControllerTypeUHCI,
+	"10": ControllerTypeOHCI,
+	"20": ControllerTypeEHCI,
+	"30": ControllerTypeXHCI,
+}
+
+// controllerType returns the type of a controller from its PCI class, e.g.
+// "0x0c0330", falling back to the name of its driver, e.g. "xhci-hcd" for a
+// platform controller
+func controllerType(pciClass, driver string) ControllerType {
+	class := strings.TrimPrefix(strings.ToLower(pciClass), "0x")
+	if len(class) == 6 && strings.HasPrefix(class, "0c03") {
+		if ctype, ok := controllerTypesByProgIf[class[4:]]; ok {
+			return ctype
+		}
+	}
+	driver = strings.ToLower(driver)
+	for _, ctype := range []ControllerType{
+		ControllerTypeXHCI, ControllerTypeEHCI, ControllerTypeOHCI, ControllerTypeUHCI,
+	} {
+		if strings.HasPrefix(driver, string(ctype)) {
+			return ctype
+		}
+	}
+	return ControllerTypeUnknown
+}
+
+// Tree describes the USB topology of the host system: the host controllers,
+// their root hubs, and the hubs and devices attached to every port.
+type Tree struct {
+	Controllers []*Controller `json:"controllers"`
+}
+
+// Controller describes a USB host controller. All the devices behind a
+// controller go along with it when it is passed through to a guest.
+type Controller struct {
+	// The sysfs name of the controller, e.g. "0000:00:14.0" for a PCI
+	// controller or "xhci-hcd.0.auto" for a platform one
+	Name string `json:"name"`
+	// The PCI address of the controller, nil if not a PCI device
+	PCIAddress *pciAddress.Address `json:"pci_address,omitempty"`
+	Type       ControllerType      `json:"type"`
+	Driver     string              `json:"driver,omitempty"`
+	// The root hubs of the controller, one per bus: an xHCI controller has a
+	// USB 2.0 and a USB 3.x one
+	RootHubs []*Hub `json:"root_hubs"`
+}
+
+// Hub describes a USB hub, either the root hub of a bus or an external hub.
+type Hub struct {
+	// The address of the hub, e.g. 1-2; root hubs have port 0, e.g. 1-0
+	Address usbAddress.Address `json:"address"`
+	// The hub device, nil for root hubs not found in Info.Devices
+	Device *Device `json:"-"`
+	// The downstream ports of the hub, occupied or not
+	Ports []*Port `json:"ports"`
+}
+
+// IsRoot returns true if the hub is the root hub of a bus.
+func (h *Hub) IsRoot() bool {
+	return h.Address.Port == "0"
+}
+
+// Port describes a downstream port of a hub.
+type Port struct {
+	// The port number, starting from 1
+	Number int `json:"number"`
+	// The sysfs name of the port, e.g. "usb1-port2" or "1-2-port3"
+	Name string `json:"name"`
+	// The address of a device attached to the port, e.g. 1-2.3
+	Address usbAddress.Address `json:"address"`
+	// The device attached to the port, nil if the port is empty
+	Device *Device `json:"device,omitempty"`
+	// The hub attached to the port, if the device is a hub
+	Hub *Hub `json:"hub,omitempty"`
+	// How the port is wired, one of the PortConnectType* values
+	ConnectType PortConnectType `json:"connect_type,omitempty"`
+	// Where the connector is on the chassis, nil if the firmware does not
+	// tell
+	PhysicalLocation *PhysicalLocation `json:"physical_location,omitempty"`
+	// The name of the port sharing the connector on the other bus of an
+	// xHCI controller, e.g. "usb2-port1" for the USB 3 side of
+	// "usb1-port1". Empty if unknown.
+	USB3Peer string `json:"usb3_peer,omitempty"`
+}
+
+// PortConnectType tells how a hub port is wired, as reported by ACPI.
+type PortConnectType string
+
+const (
+	PortConnectTypeUnknown PortConnectType = ""
+	// PortConnectTypeHotplug is a port with a user visible connector
+	PortConnectTypeHotplug PortConnectType = "hotplug"
+	// PortConnectTypeHardwired is a port wired to a device soldered on the
+	// board, e.g. a webcam or a Bluetooth adapter
+	PortConnectTypeHardwired PortConnectType = "hardwired"
+	// PortConnectTypeNotUsed is a port with nothing wired to it
+	PortConnectTypeNotUsed PortConnectType = "not used"
+)
+
+// PhysicalLocation describes where the connector of a port is on the
+// chassis, from the ACPI _PLD object of the port.
+type PhysicalLocation struct {
+	// The panel of the chassis the connector is on: "top", "bottom",
+	// "left", "right", "front", "back" or "unknown"
+	Panel string `json:"panel"`
+	// The position of the connector on the panel: "upper", "center" or
+	// "lower" vertically, and "left", "center" or "right" horizontally
+	VerticalPosition   string `json:"vertical_position"`
+	HorizontalPosition string `json:"horizontal_position"`
+	// True if the connector is on a docking station
+	Dock bool `json:"dock"`
+	// True if the connector is on the lid of a laptop
+	Lid bool `json:"lid"`
+}
+
+// String returns a label such as "front upper left", omitting the unknown
+// parts.
+func (l *PhysicalLocation) String() string {
+	parts := []string{}
+	if l.Dock {
+		parts = append(parts, "dock")
+	}
+	if l.Lid {
+		parts = append(parts, "lid")
+	}
+	for _, part := range []string{l.Panel, l.VerticalPosition, l.HorizontalPosition} {
+		if part != "" && part != "unknown" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// IsUserVisible returns true if the port has a connector users can plug a
+// device into, or may have one when the firmware does not tell.
+func (p *Port) IsUserVisible() bool {
+	return p.ConnectType != PortConnectTypeHardwired && p.ConnectType != PortConnectTypeNotUsed
+}
+
+// IsEmpty returns true if no device is attached to the port.
+func (p *Port) IsEmpty() bool {
+	return p.Device == nil
+}
+
+// childAddress returns the address a device attached to the given port of
+// the hub at the given address gets, e.g. 1-2 for port 2 of the root hub of
+// bus 1, or 1-2.3 for port 3 of hub 1-2
+func childAddress(hub usbAddress.Address, port int) usbAddress.Address {
+	if hub.Port == "0" {
+		return usbAddress.Address{Busnum: hub.Busnum, Port: fmt.Sprintf("%d", port)}
+	}
+	return usbAddress.Address{Busnum: hub.Busnum, Port: fmt.Sprintf("%s.%d", hub.Port, port)}
+}
+
+// Ports returns all the ports behind the controller, including the ones of
+// external hubs.
+func (c *Controller) Ports() []*Port {
+	ports := []*Port{}
+	var walk func(hub *Hub)
+	walk = func(hub *Hub) {
+		for _, port := range hub.Ports {
+			ports = append(ports, port)
+			if port.Hub != nil {
+				walk(port.Hub)
+			}
+		}
+	}
+	for _, hub := range c.RootHubs {
+		walk(hub)
+	}
+	return ports
+}
+
+// Devices returns the devices attached behind the controller, including
+// hubs, i.e. the devices passed through to a guest along with it.
+func (c *Controller) Devices() []*Device {
+	devs := []*Device{}
+	for _, port := range c.Ports() {
+		if port.Device != nil {
+			devs = append(devs, port.Device)
+		}
+	}
+	return devs
+}
+
+// PortByName returns the port with the given sysfs name, e.g. "usb2-port1",
+// or nil if not found. Use it to follow Port.USB3Peer.
+func (t *Tree) PortByName(name string) *Port {
+	for _, ctrl := range t.Controllers {
+		for _, port := range ctrl.Ports() {
+			if port.Name == name {
+				return port
+			}
+		}
+	}
+	return nil
+}
+
+// ControllerOf returns the controller the device at the given address is
+// attached behind, or nil if unknown.
+func (t *Tree) ControllerOf(addr usbAddress.Address) *Controller {
+	for _, ctrl := range t.Controllers {
+		for _, hub := range ctrl.RootHubs {
+			if hub.Address.Busnum == addr.Busnum {
+				return ctrl
+			}
+		}
+	}
+	return nil
+}
+
+func (t *Tree) String() string {
+	var sb strings.Builder
+	for _, ctrl := range t.Controllers {
+		fmt.Fprintf(&sb, "controller %s (%s", ctrl.Name, ctrl.Type)
+		if ctrl.Driver != "" {
+			fmt.Fprintf(&sb, ", %s", ctrl.Driver)
+		}
+		sb.WriteString(")\n")
+		for _, hub := range ctrl.RootHubs {
+			fmt.Fprintf(&sb, " bus %d root hub", hub.Address.Busnum)
+			if hub.Device != nil && hub.Device.SpeedClass != SpeedClassUnknown {
+				fmt.Fprintf(&sb, " (%s speed)", hub.Device.SpeedClass)
+			}
+			sb.WriteString("\n")
+			writeHubPorts(&sb, hub, 2)
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func writeHubPorts(sb *strings.Builder, hub *Hub, depth int) {
+	indent := strings.Repeat(" ", depth)
+	for _, port := range hub.Ports {
+		fmt.Fprintf(sb, "%sport %d", indent, port.Number)
+		if port.PhysicalLocation != nil {
+			if label := port.PhysicalLocation.String(); label != "" {
+				fmt.Fprintf(sb, " (%s)", label)
+			}
+		}
+		if port.ConnectType == PortConnectTypeHardwired || port.ConnectType == PortConnectTypeNotUsed {
+			fmt.Fprintf(sb, " [%s]", port.ConnectType)
+		}
+		sb.WriteString(": ")
+		if port.IsEmpty() {
+			sb.WriteString("empty\n")
+			continue
+		}
+		fmt.Fprintf(sb, "%s %s", port.Address, port.Device.Name())
+		if port.Hub != nil {
+			sb.WriteString(" (hub)")
+		}
+		sb.WriteString("\n")
+		if port.Hub != nil {
+			writeHubPorts(sb, port.Hub, depth+1)
+		}
+	}
+}
+
+// simple private struct used to encapsulate the USB tree in a top-level
+// "usb_tree" YAML/JSON map/object key
+type treePrinter struct {
+	Tree *Tree `json:"usb_tree"`
+}
+
+// YAMLString returns a string with the USB tree formatted as YAML under a
+// top-level "usb_tree:" key
+func (t *Tree) YAMLString() string {
+	return marshal.SafeYAML(treePrinter{t})
+}
+
+// JSONString returns a string with the USB tree formatted as JSON under a
+// top-level "usb_tree:" key
+func (t *Tree) JSONString(indent bool) string {
+	return marshal.SafeJSON(treePrinter{t}, indent)
+}
@@ -0,0 +1,148 @@
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package usb
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/zededa/ghw/pkg/linuxpath"
+	pciAddress "github.com/zededa/ghw/pkg/pci/address"
+	usbAddress "github.com/zededa/ghw/pkg/usb/address"
+)
+
+var (
+	rootHubNameRe = regexp.MustCompile(`^usb(\d+)$`)
+	portNameRe    = regexp.MustCompile(`-port(\d+)$`)
+)
+
+// hubClass is the bDeviceClass of hubs
+const hubClass = "09"
+
+// getTree returns the USB topology, from the root hubs found under
+// /sys/bus/usb/devices, e.g. usb1, down through their ports
+func getTree(paths *linuxpath.Paths, devs []*Device) *Tree {
+	byAddress := make(map[usbAddress.Address]*Device, len(devs))
+	for _, dev := range devs {
+		byAddress[dev.Address] = dev
+	}
+
+	tree := &Tree{Controllers: []*Controller{}}
+	ctrlsByDir := map[string]*Controller{}
+	entries, _ := os.ReadDir(paths.SysBusUsbDevices)
+	for _, entry := range entries {
+		m := rootHubNameRe.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		busnum, err := strconv.ParseUint(m[1], 10, 16)
+		if err != nil {
+			continue
+		}
+		hubDir, err := resolveDeviceLink(paths.SysBusUsbDevices, entry.Name())
+		if err != nil {
+			continue
+		}
+		ctrlDir := filepath.Dir(hubDir)
+		ctrl, found := ctrlsByDir[ctrlDir]
+		if !found {
+			ctrl = getController(ctrlDir)
+			ctrlsByDir[ctrlDir] = ctrl
+			tree.Controllers = append(tree.Controllers, ctrl)
+		}
+		addr := usbAddress.Address{Busnum: uint16(busnum), Port: "0"}
+		ctrl.RootHubs = append(ctrl.RootHubs, getHub(hubDir, addr, byAddress))
+	}
+
+	sort.Slice(tree.Controllers, func(i, j int) bool {
+		return tree.Controllers[i].Name < tree.Controllers[j].Name
+	})
+	for _, ctrl := range tree.Controllers {
+		sort.Slice(ctrl.RootHubs, func(i, j int) bool {
+			return ctrl.RootHubs[i].Address.Busnum < ctrl.RootHubs[j].Address.Busnum
+		})
+	}
+	return tree
+}
+
+// getController returns the host controller described by the given sysfs
+// directory, the parent of its root hubs
+func getController(ctrlDir string) *Controller {
+	ctrl := &Controller{
+		Name:     filepath.Base(ctrlDir),
+		RootHubs: []*Hub{},
+	}
+	ctrl.PCIAddress = pciAddress.FromString(ctrl.Name)
+	if dest, err := os.Readlink(filepath.Join(ctrlDir, "driver")); err == nil {
+		ctrl.Driver = filepath.Base(dest)
+	}
+	ctrl.Type = controllerType(slurp(filepath.Join(ctrlDir, "class")), ctrl.Driver)
+	return ctrl
+}
+
+// getHub returns the hub described by the given sysfs directory along with
+// its ports, which are found under the hub interface directory, e.g.
+// usb1/1-0:1.0/usb1-port1 or 1-2/1-2:1.0/1-2-port1
+func getHub(hubDir string, addr usbAddress.Address, byAddress map[usbAddress.Address]*Device) *Hub {
+	hub := &Hub{
+		Address: addr,
+		Device:  byAddress[addr],
+		Ports:   []*Port{},
+	}
+	portDirs, _ := filepath.Glob(filepath.Join(hubDir, "*:*", "*-port*"))
+	for _, portDir := range portDirs {
+		m := portNameRe.FindStringSubmatch(portDir)
+		if m == nil {
+			continue
+		}
+		num, err := strconv.Atoi(m[1])
+		if err != nil {
+			continue
+		}
+		port := &Port{
+			Number:  num,
+			Name:    filepath.Base(portDir),
+			Address: childAddress(addr, num),
+		}
+		fillPortLocation(portDir, port)
+		port.Device = byAddress[port.Address]
+		if port.Device != nil && port.Device.Class == hubClass {
+			port.Hub = getHub(filepath.Join(hubDir, port.Address.String()), port.Address, byAddress)
+		}
+		hub.Ports = append(hub.Ports, port)
+	}
+	sort.Slice(hub.Ports, func(i, j int) bool {
+		return hub.Ports[i].Number < hub.Ports[j].Number
+	})
+	return hub
+}
+
+// fillPortLocation sets the wiring, physical location and USB 3 peer of the
+// port described by the given sysfs directory. Kernels expose them for ports
+// described by ACPI.
+func fillPortLocation(portDir string, port *Port) {
+	port.ConnectType = PortConnectType(slurp(filepath.Join(portDir, "connect_type")))
+	if port.ConnectType == "unknown" {
+		port.ConnectType = PortConnectTypeUnknown
+	}
+	if dest, err := os.Readlink(filepath.Join(portDir, "peer")); err == nil {
+		port.USB3Peer = filepath.Base(dest)
+	}
+	locDir := filepath.Join(portDir, "physical_location")
+	if _, err := os.Stat(locDir); err != nil {
+		return
+	}
+	port.PhysicalLocation = &PhysicalLocation{
+		Panel:              slurp(filepath.Join(locDir, "panel")),
+		VerticalPosition:   slurp(filepath.Join(locDir, "vertical_position")),
+		HorizontalPosition: slurp(filepath.Join(locDir, "horizontal_position")),
+		Dock:               slurp(filepath.Join(locDir, "dock")) == "yes",
+		Lid:                slurp(filepath.Join(locDir, "lid")) == "yes",
+	}
+}
@@ -16,23 +16,112 @@ import (
 )
 
 type Device struct {
-	Driver         string        `json:"driver"`
-	Type           string        `json:"type"`
-	VendorID       string        `json:"vendor_id"`
-	ProductID      string        `json:"product_id"`
-	Product        string        `json:"product"`
-	RevisionID     string        `json:"revision_id"`
-	Interface      string        `json:"interface"`
-	Devnum         string        `json:"devnum"`
-	Parent         bus.BusParent `json:"parent,omitempty"`
-	Class          string        `json:"class"`
-	Subclass       string        `json:"subclass"`
-	Protocol       string        `json:"protocol"`
-	Controller     string        `json:"controller,omitempty"`
+	Driver     string        `json:"driver"`
+	Type       string        `json:"type"`
+	VendorID   string        `json:"vendor_id"`
+	ProductID  string        `json:"product_id"`
+	Product    string        `json:"product"`
+	RevisionID string        `json:"revision_id"`
+	Interface  string        `json:"interface"`
+	Devnum     string        `json:"devnum"`
+	Parent     bus.BusParent `json:"parent,omitempty"`
+	Class      string        `json:"class"`
+	Subclass   string        `json:"subclass"`
+	Protocol   string        `json:"protocol"`
+	// Names from the USB ID database, empty if unknown
+	VendorName  string `json:"vendor_name,omitempty"`
+	ProductName string `json:"product_name,omitempty"`
+	ClassName   string `json:"class_name,omitempty"`
+	Controller  string `json:"controller,omitempty"`
+	// The negotiated speed in Mbps, e.g. 480, or 0 if unknown
+	Speed      float64    `json:"speed_mbps"`
+	SpeedClass SpeedClass `json:"speed_class"`
+	// The USB version the device complies with (bcdUSB), e.g. "2.00"
+	Version string `json:"version"`
+	// True if the device declares SuperSpeed support in its BOS descriptor,
+	// which USB 3 devices do even when they fall back to USB 2 and report
+	// version 2.10. Only known on kernels exposing bos_descriptors.
+	SuperSpeedCapable bool `json:"superspeed_capable,omitempty"`
+	// The maximum current the device draws from the bus in mA (bMaxPower)
+	MaxPower          int    `json:"max_power_ma"`
+	NumConfigurations int    `json:"num_configurations"`
+	Manufacturer      string `json:"manufacturer,omitempty"`
+	Serial            string `json:"serial,omitempty"`
+	// False if the device has been deauthorized, in which case no driver
+	// can bind to its interfaces
+	Authorized bool `json:"authorized"`
+	// One of "removable", "fixed" or "unknown"
+	Removable string `json:"removable,omitempty"`
+	// The kernel devices created for the device itself rather than for one
+	// of its interfaces, which is rare
+	ClassDevices []*ClassDevice `json:"class_devices,omitempty"`
+	// All the interfaces of the device, for every configuration
+	Interfaces     []*Interface `json:"interfaces,omitempty"`
 	UEventFilePath string
 	usbAddress.Address
 }
 
+// Interface describes a USB interface, i.e. one of the functions of a
+// device. Composite devices such as LTE modems or multi-port serial adapters
+// expose several interfaces, each bound to its own driver.
+type Interface struct {
+	// The sysfs name of the interface, <bus>-<port>:<config>.<interface>,
+	// e.g. "1-2:1.0"
+	Name string `json:"name"`
+	// bInterfaceNumber, in hexadecimal
+	Number string `json:"number"`
+	// bAlternateSetting, in hexadecimal
+	AlternateSetting string `json:"alternate_setting"`
+	// bInterfaceClass, bInterfaceSubClass and bInterfaceProtocol, in
+	// hexadecimal
+	Class    string `json:"class"`
+	Subclass string `json:"subclass"`
+	Protocol string `json:"protocol"`
+	// The class name from the USB ID database, empty if unknown
+	ClassName string `json:"class_name,omitempty"`
+	// The driver bound to the interface, empty if none
+	Driver string `json:"driver"`
+	// bNumEndpoints: the number of endpoints, not counting endpoint 0
+	NumEndpoints int `json:"num_endpoints"`
+	// The interface string descriptor, if any
+	Description string `json:"description,omitempty"`
+	// The kernel devices the driver of the interface created, e.g. ttyUSB0
+	ClassDevices []*ClassDevice `json:"class_devices,omitempty"`
+}
+
+// ClassDevice is a kernel device created by the driver of a USB device or
+// interface, as listed under /sys/class, e.g. ttyUSB0 in the tty class.
+type ClassDevice struct {
+	// The device class, one of "tty", "net", "block", "input",
+	// "video4linux", "sound" or "hidraw"
+	Class string `json:"class"`
+	// The name of the device in its class, e.g. "ttyUSB0", "wwan0" or "sdb"
+	Name string `json:"name"`
+}
+
+func (c *ClassDevice) String() string {
+	return c.Class + "/" + c.Name
+}
+
+func (i *Interface) String() string {
+	driver := i.Driver
+	if driver == "" {
+		driver = "(none)"
+	}
+	str := fmt.Sprintf(
+		"%s class=%s/%s/%s driver=%s endpoints=%d",
+		i.Name, i.Class, i.Subclass, i.Protocol, driver, i.NumEndpoints,
+	)
+	if len(i.ClassDevices) > 0 {
+		names := make([]string, 0, len(i.ClassDevices))
+		for _, cdev := range i.ClassDevices {
+			names = append(names, cdev.String())
+		}
+		str += " devices=" + strings.Join(names, ",")
+	}
+	return str
+}
+
 func (d Device) String() string {
 	address := ""
 	if d.Port != "" {
@@ -48,7 +137,10 @@ func (d Device) String() string {
 		{"vendorID", d.VendorID},
 		{"productID", d.ProductID},
 		{"product", d.Product},
+		{"vendorName", d.VendorName},
+		{"productName", d.ProductName},
 		{"revisionID", d.RevisionID},
+		{"speed", string(d.SpeedClass)},
 		{"interface", d.Interface},
 		{"pci_address", d.Controller},
 		{"address", address},
@@ -104,9 +196,34 @@ func (d Device) String() string {
 	return str.String()
 }
 
+// AllClassDevices returns the kernel devices created for the device and all
+// its interfaces.
+func (d *Device) AllClassDevices() []*ClassDevice {
+	devs := append([]*ClassDevice{}, d.ClassDevices...)
+	for _, iface := range d.Interfaces {
+		devs = append(devs, iface.ClassDevices...)
+	}
+	return devs
+}
+
+// isRootHub returns true if the device is the root hub of a host controller
+func (d *Device) isRootHub() bool {
+	return d.Port == "0"
+}
+
 // Info describes all network interface controllers (NICs) in the host system.
 type Info struct {
 	Devices []*Device `json:"devices"`
+	tree    *Tree
+}
+
+// Tree returns the USB topology of the host system: host controllers, hubs
+// and ports.
+func (i *Info) Tree() *Tree {
+	if i.tree == nil {
+		return &Tree{Controllers: []*Controller{}}
+	}
+	return i.tree
 }
 
 // String returns a short string with information about the networking on the
@@ -118,6 +235,41 @@ func (i *Info) String() string {
 	)
 }
 
+// PassthroughDevices returns the devices which can be passed through to a
+// guest: one per USB address, since the interfaces of a composite device
+// cannot be assigned separately. Root hubs are left out, as they belong to
+// their host controller.
+func (i *Info) PassthroughDevices() []*Device {
+	devs := make([]*Device, 0, len(i.Devices))
+	for _, dev := range i.Devices {
+		if !dev.isRootHub() {
+			devs = append(devs, dev)
+		}
+	}
+	return devs
+}
+
+// FindByClassDevice returns the device, and interface if any, which the
+// kernel device with the given class and name, e.g. "tty" and "ttyUSB0",
+// belongs to. Returns nil if the kernel device is not a USB one.
+func (i *Info) FindByClassDevice(class, name string) (*Device, *Interface) {
+	for _, dev := range i.Devices {
+		for _, cdev := range dev.ClassDevices {
+			if cdev.Class == class && cdev.Name == name {
+				return dev, nil
+			}
+		}
+		for _, iface := range dev.Interfaces {
+			for _, cdev := range iface.ClassDevices {
+				if cdev.Class == class && cdev.Name == name {
+					return dev, iface
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
 // New returns a pointer to an Info struct that contains information about the
 // USB devices on the host system
 func New(opt ...option.Option) (*Info, error) {
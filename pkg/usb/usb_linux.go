@@ -11,23 +11,23 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
 
+	"github.com/zededa/ghw/pkg/bus"
 	"github.com/zededa/ghw/pkg/linuxpath"
 	"github.com/zededa/ghw/pkg/option"
-	"github.com/zededa/ghw/pkg/pci"
-	pciAddress "github.com/zededa/ghw/pkg/pci/address"
 	usbAddress "github.com/zededa/ghw/pkg/usb/address"
 )
 
-var pciBDFRe = regexp.MustCompile(`(?i)\b([0-9a-f]{4}):([0-9a-f]{2}):([0-9a-f]{2})\.([0-7])\b`)
-
 func (i *Info) load(opts *option.Options) error {
 	var errs []error
 
 	i.Devices, errs = usbs(opts)
+	i.setNames(loadUSBDB(opts))
+	paths := linuxpath.New(opts)
+	setClassDevices(paths, i.Devices)
+	i.tree = getTree(paths, i.Devices)
 
 	if len(errs) == 0 {
 		return nil
@@ -93,21 +93,29 @@ func usbs(opts *option.Options) ([]*Device, []error) {
 		return devs, []error{err}
 	}
 
-	// filter out USB devices with the same address
-	// this happens if a USB device has several "functions"
-	// as functions cannot be passed-through separately,
-	// we ignore these
-	seen := map[usbAddress.Address]struct{}{}
+	// the sysfs entries of a device with several interfaces ("functions")
+	// share the same address: the device entry, e.g. 1-2, sorts before the
+	// ones of its interfaces, e.g. 1-2:1.0, which are attached to it
+	devsByAddress := map[usbAddress.Address]*Device{}
 	for _, dir := range usbDevicesDirs {
-		fullDir, err := os.Readlink(filepath.Join(paths.SysBusUsbDevices, dir.Name()))
+		fullDir, err := resolveDeviceLink(paths.SysBusUsbDevices, dir.Name())
 		if err != nil {
 			continue
 		}
-		if !filepath.IsAbs(fullDir) {
-			fullDir, err = filepath.Abs(filepath.Join(paths.SysBusUsbDevices, fullDir))
-			if err != nil {
-				continue
+
+		busnum, port, err := ExtractUSBBusnumPort(fullDir)
+		if err != nil {
+			continue
+		}
+		var iface *Interface
+		if isInterfaceName(dir.Name()) {
+			iface = getInterface(fullDir)
+		}
+		if dev, found := devsByAddress[usbAddress.Address{Busnum: busnum, Port: port}]; found {
+			if iface != nil {
+				dev.Interfaces = append(dev.Interfaces, iface)
 			}
+			continue
 		}
 
 		dev := Device{}
@@ -125,66 +133,80 @@ func usbs(opts *option.Options) ([]*Device, []error) {
 		dev.Interface = slurp(filepath.Join(fullDir, "interface"))
 		dev.Product = slurp(filepath.Join(fullDir, "product"))
 		dev.Devnum = slurp(filepath.Join(fullDir, "devnum"))
-		dev.Busnum, dev.Port, err = ExtractUSBBusnumPort(fullDir)
-		if err != nil {
-			continue
-		}
-		_, found := seen[dev.Address]
-		if found {
-			continue
-		}
-		seen[dev.Address] = struct{}{}
+		dev.Busnum, dev.Port = busnum, port
+		devsByAddress[dev.Address] = &dev
 		dev.Class = slurp(filepath.Join(fullDir, "bDeviceClass"))
 		dev.Subclass = slurp(filepath.Join(fullDir, "bDeviceSubClass"))
 		dev.Protocol = slurp(filepath.Join(fullDir, "bDeviceProtocol"))
-
-		// Parent logic
-		parentDir := filepath.Dir(fullDir)
-		// Check if parent is USB
-		if _, err := os.Stat(filepath.Join(parentDir, "busnum")); err == nil {
-			busnum, port, err := ExtractUSBBusnumPort(parentDir)
-			if err == nil && port != "" && port != dev.Port {
-				dev.Parent.USB = &usbAddress.Address{
-					Busnum: busnum,
-					Port:   port,
-				}
-			}
+		dev.Speed = parseSpeed(slurp(filepath.Join(fullDir, "speed")))
+		dev.SpeedClass = speedClass(dev.Speed)
+		dev.Version = slurp(filepath.Join(fullDir, "version"))
+		if bos, err := os.ReadFile(filepath.Join(fullDir, "bos_descriptors")); err == nil {
+			dev.SuperSpeedCapable = hasSuperSpeedCapability(bos)
 		}
-
-		sysLessFullDir := strings.TrimPrefix(fullDir, paths.SysBlock)
-		pciAddr := pci.FindPCIAddress(sysLessFullDir)
-		if m := pciBDFRe.FindStringSubmatch(pciAddr); m != nil {
-			dev.Parent.PCI = &pciAddress.Address{
-				Domain:   m[1],
-				Bus:      m[2],
-				Device:   m[3],
-				Function: m[4],
-			}
+		dev.MaxPower = parseMaxPower(slurp(filepath.Join(fullDir, "bMaxPower")))
+		dev.NumConfigurations, _ = strconv.Atoi(slurp(filepath.Join(fullDir, "bNumConfigurations")))
+		dev.Manufacturer = slurp(filepath.Join(fullDir, "manufacturer"))
+		dev.Serial = slurp(filepath.Join(fullDir, "serial"))
+		dev.Authorized = slurp(filepath.Join(fullDir, "authorized")) == "1"
+		dev.Removable = slurp(filepath.Join(fullDir, "removable"))
+		if iface != nil {
+			dev.Interfaces = append(dev.Interfaces, iface)
 		}
 
+		dev.Parent = bus.ParentFromPath(filepath.Dir(fullDir))
+
 		devs = append(devs, &dev)
 	}
 
 	return devs, errs
 }
 
-// ExtractUSBBusnumPort extracts busnum and port number out of a sysfs device path
-func ExtractUSBBusnumPort(path string) (uint16, string, error) {
-	var busnum uint16
+// resolveDeviceLink returns the absolute path of the sysfs directory the
+// given entry of /sys/bus/usb/devices links to
+func resolveDeviceLink(busDir, name string) (string, error) {
+	fullDir, err := os.Readlink(filepath.Join(busDir, name))
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(fullDir) {
+		return filepath.Abs(filepath.Join(busDir, fullDir))
+	}
+	return fullDir, nil
+}
 
-	re := regexp.MustCompile(`\/usb\d+(\/\d+\-[\d\.]+)*(\/(\d+)\-([\d\.]+))`)
+// isInterfaceName returns true if the given sysfs entry name is the one of
+// a USB interface, e.g. 1-2:1.0, rather than of a device, e.g. 1-2
+func isInterfaceName(name string) bool {
+	return strings.Contains(name, ":")
+}
 
-	matches := re.FindStringSubmatch(path)
-	if len(matches) < 3 {
-		return busnum, "", fmt.Errorf("could not extract usb portnum from %s", path)
+// getInterface returns the interface described by the given sysfs directory,
+// e.g. /sys/devices/pci0000:00/0000:00:14.0/usb1/1-2/1-2:1.0
+func getInterface(ifaceDir string) *Interface {
+	iface := &Interface{
+		Name:             filepath.Base(ifaceDir),
+		Number:           slurp(filepath.Join(ifaceDir, "bInterfaceNumber")),
+		AlternateSetting: slurp(filepath.Join(ifaceDir, "bAlternateSetting")),
+		Class:            slurp(filepath.Join(ifaceDir, "bInterfaceClass")),
+		Subclass:         slurp(filepath.Join(ifaceDir, "bInterfaceSubClass")),
+		Protocol:         slurp(filepath.Join(ifaceDir, "bInterfaceProtocol")),
+		Description:      slurp(filepath.Join(ifaceDir, "interface")),
 	}
-	port := matches[len(matches)-1]
-	busnumString := matches[len(matches)-2]
-	busnum64, err := strconv.ParseUint(busnumString, 10, 16)
-	if err != nil {
-		return 0, port, fmt.Errorf("could not extract usb busnum from %s", path)
+	if dest, err := os.Readlink(filepath.Join(ifaceDir, "driver")); err == nil {
+		iface.Driver = filepath.Base(dest)
+	}
+	if numEndpoints, err := strconv.ParseUint(slurp(filepath.Join(ifaceDir, "bNumEndpoints")), 16, 8); err == nil {
+		iface.NumEndpoints = int(numEndpoints)
 	}
-	busnum = uint16(busnum64)
+	return iface
+}
 
-	return busnum, port, nil
+// ExtractUSBBusnumPort extracts busnum and port number out of a sysfs device path
+func ExtractUSBBusnumPort(path string) (uint16, string, error) {
+	addr := usbAddress.FromPath(path)
+	if addr == nil {
+		return 0, "", fmt.Errorf("could not extract usb portnum from %s", path)
+	}
+	return addr.Busnum, addr.Port, nil
 }
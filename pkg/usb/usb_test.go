@@ -21,6 +21,7 @@ import (
 	pciAddress "github.com/zededa/ghw/pkg/pci/address"
 	"github.com/zededa/ghw/pkg/snapshot"
 	usbAddress "github.com/zededa/ghw/pkg/usb/address"
+	"github.com/zededa/ghw/pkg/usb/usbdb"
 	"github.com/zededa/ghw/testdata"
 )
 
@@ -184,3 +185,566 @@ func TestDeviceString(t *testing.T) {
 		})
 	}
 }
+
+// fakeUSBDevice describes an entry of a fake /sys/bus/usb/devices tree
+type fakeUSBDevice struct {
+	// path relative to /sys/devices
+	path  string
+	files map[string]string
+	// name of the bound driver, if any
+	driver string
+}
+
+// writeFakeUSBTree creates a fake sysfs tree under root holding the given
+// devices and interfaces
+func writeFakeUSBTree(t *testing.T, root string, devs []fakeUSBDevice) {
+	t.Helper()
+	busDir := filepath.Join(root, "sys", "bus", "usb", "devices")
+	if err := os.MkdirAll(busDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, dev := range devs {
+		devDir := filepath.Join(root, "sys", "devices", dev.path)
+		if err := os.MkdirAll(devDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range dev.files {
+			fp := filepath.Join(devDir, name)
+			if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(fp, []byte(content+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if dev.driver != "" {
+			driverDir := filepath.Join(root, "sys", "bus", "usb", "drivers", dev.driver)
+			if err := os.MkdirAll(driverDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Symlink(driverDir, filepath.Join(devDir, "driver")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		target := filepath.Join("..", "..", "..", "devices", dev.path)
+		if err := os.Symlink(target, filepath.Join(busDir, filepath.Base(dev.path))); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestInterfaces(t *testing.T) {
+	root := t.TempDir()
+	const ctrl = "pci0000:00/0000:00:14.0"
+	writeFakeUSBTree(t, root, []fakeUSBDevice{
+		{
+			path: ctrl + "/usb1/1-0:1.0",
+			files: map[string]string{
+				"uevent":             "DEVTYPE=usb_interface\nDRIVER=hub\nPRODUCT=1d6b/2/606\nTYPE=9/0/1",
+				"bInterfaceNumber":   "00",
+				"bAlternateSetting":  " 0",
+				"bInterfaceClass":    "09",
+				"bInterfaceSubClass": "00",
+				"bInterfaceProtocol": "00",
+				"bNumEndpoints":      "01",
+			},
+			driver: "hub",
+		},
+		{
+			path: ctrl + "/usb1/1-2",
+			files: map[string]string{
+				"uevent":          "DEVTYPE=usb_device\nDRIVER=usb\nPRODUCT=2c7c/125/318\nTYPE=239/2/1",
+				"busnum":          "1",
+				"devnum":          "3",
+				"product":         "EG25-G",
+				"bDeviceClass":    "ef",
+				"bDeviceSubClass": "02",
+				"bDeviceProtocol": "01",
+			},
+			driver: "usb",
+		},
+		{
+			path: ctrl + "/usb1/1-2/1-2:1.0",
+			files: map[string]string{
+				"uevent":             "DEVTYPE=usb_interface\nDRIVER=option\nPRODUCT=2c7c/125/318",
+				"bInterfaceNumber":   "00",
+				"bAlternateSetting":  " 0",
+				"bInterfaceClass":    "ff",
+				"bInterfaceSubClass": "ff",
+				"bInterfaceProtocol": "ff",
+				"bNumEndpoints":      "02",
+			},
+			driver: "option",
+		},
+		{
+			path: ctrl + "/usb1/1-2/1-2:1.4",
+			files: map[string]string{
+				"uevent":             "DEVTYPE=usb_interface\nDRIVER=qmi_wwan\nPRODUCT=2c7c/125/318",
+				"bInterfaceNumber":   "04",
+				"bAlternateSetting":  " 0",
+				"bInterfaceClass":    "ff",
+				"bInterfaceSubClass": "ff",
+				"bInterfaceProtocol": "ff",
+				"bNumEndpoints":      "03",
+				"interface":          "Network Interface",
+			},
+			driver: "qmi_wwan",
+		},
+		{
+			path: ctrl + "/usb1/1-2/1-2:1.5",
+			files: map[string]string{
+				"bInterfaceNumber":   "05",
+				"bAlternateSetting":  " 0",
+				"bInterfaceClass":    "0a",
+				"bInterfaceSubClass": "00",
+				"bInterfaceProtocol": "00",
+				"bNumEndpoints":      "02",
+			},
+		},
+	})
+
+	info, err := New(option.WithChroot(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Devices) != 2 {
+		t.Fatalf("expected 2 devices, got %d: %v", len(info.Devices), info.Devices)
+	}
+
+	modem := info.Devices[1]
+	if modem.Address.String() != "1-2" || modem.Driver != "usb" {
+		t.Fatalf("expected the modem device entry at 1-2, got %v", modem)
+	}
+	expected := []*Interface{
+		{
+			Name:             "1-2:1.0",
+			Number:           "00",
+			AlternateSetting: "0",
+			Class:            "ff",
+			Subclass:         "ff",
+			Protocol:         "ff",
+			Driver:           "option",
+			NumEndpoints:     2,
+		},
+		{
+			Name:             "1-2:1.4",
+			Number:           "04",
+			AlternateSetting: "0",
+			Class:            "ff",
+			Subclass:         "ff",
+			Protocol:         "ff",
+			Driver:           "qmi_wwan",
+			NumEndpoints:     3,
+			Description:      "Network Interface",
+		},
+		{
+			Name:             "1-2:1.5",
+			Number:           "05",
+			AlternateSetting: "0",
+			Class:            "0a",
+			Subclass:         "00",
+			Protocol:         "00",
+			NumEndpoints:     2,
+		},
+	}
+	if !reflect.DeepEqual(modem.Interfaces, expected) {
+		t.Fatalf("expected interfaces %v, got %v", expected, modem.Interfaces)
+	}
+
+	rootHub := info.Devices[0]
+	if len(rootHub.Interfaces) != 1 || rootHub.Interfaces[0].Driver != "hub" {
+		t.Fatalf("expected the root hub interface, got %v", rootHub.Interfaces)
+	}
+
+	passthrough := info.PassthroughDevices()
+	if len(passthrough) != 1 || passthrough[0] != modem {
+		t.Fatalf("expected only the modem to be passed through, got %v", passthrough)
+	}
+}
+
+func TestDeviceDescriptors(t *testing.T) {
+	root := t.TempDir()
+	writeFakeUSBTree(t, root, []fakeUSBDevice{
+		{
+			path: "pci0000:00/0000:00:14.0/usb2/2-1",
+			files: map[string]string{
+				"uevent":             "DEVTYPE=usb_device\nDRIVER=usb\nPRODUCT=781/5583/100\nTYPE=0/0/0",
+				"busnum":             "2",
+				"speed":              "480",
+				"version":            " 3.20",
+				"bMaxPower":          "896mA",
+				"bNumConfigurations": "1",
+				"manufacturer":       " SanDisk",
+				"serial":             "4C530001230830117421",
+				"authorized":         "1",
+				"removable":          "removable",
+			},
+		},
+	})
+
+	info, err := New(option.WithChroot(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Devices) != 1 {
+		t.Fatalf("expected 1 device, got %d", len(info.Devices))
+	}
+	dev := info.Devices[0]
+	if dev.Speed != 480 || dev.SpeedClass != SpeedClassHigh {
+		t.Fatalf("expected high speed 480 Mbps, got %v %v", dev.Speed, dev.SpeedClass)
+	}
+	if dev.Version != "3.20" || dev.MaxPower != 896 || dev.NumConfigurations != 1 {
+		t.Fatalf("unexpected descriptors: %+v", dev)
+	}
+	if dev.Manufacturer != "SanDisk" || dev.Serial != "4C530001230830117421" {
+		t.Fatalf("unexpected strings: %q %q", dev.Manufacturer, dev.Serial)
+	}
+	if !dev.Authorized || dev.Removable != RemovableRemovable {
+		t.Fatalf("expected an authorized removable device, got %+v", dev)
+	}
+	if !dev.IsSpeedDegraded() {
+		t.Fatalf("expected a USB 3 device at high speed to be degraded")
+	}
+}
+
+func TestSpeedClass(t *testing.T) {
+	for speed, expected := range map[string]SpeedClass{
+		"1.5":   SpeedClassLow,
+		"12":    SpeedClassFull,
+		"480":   SpeedClassHigh,
+		"5000":  SpeedClassSuper,
+		"10000": SpeedClassSuperPlus,
+		"20000": SpeedClassSuperPlus,
+		"":      SpeedClassUnknown,
+	} {
+		if class := speedClass(parseSpeed(speed)); class != expected {
+			t.Errorf("speed %q: expected %q, got %q", speed, expected, class)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	root := t.TempDir()
+	writeFakeUSBTree(t, root, []fakeUSBDevice{
+		{
+			path: "pci0000:00/0000:00:14.0/usb1/1-3",
+			files: map[string]string{
+				"uevent":       "DEVTYPE=usb_device\nDRIVER=usb\nPRODUCT=403/6001/600\nTYPE=0/0/0",
+				"busnum":       "1",
+				"bDeviceClass": "00",
+			},
+		},
+		{
+			path: "pci0000:00/0000:00:14.0/usb1/1-3/1-3:1.0",
+			files: map[string]string{
+				"bInterfaceClass": "ff",
+			},
+		},
+	})
+	db, err := usbdb.FromReader(strings.NewReader(
+		"0403  Future Technology Devices International, Ltd\n" +
+			"\t6001  FT232 Serial (UART) IC\n" +
+			"C 00  (Defined at Interface level)\n" +
+			"C ff  Vendor Specific Class\n",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := New(option.WithChroot(root), WithUSBDB(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev := info.Devices[0]
+	if dev.VendorName != "Future Technology Devices International, Ltd" ||
+		dev.ProductName != "FT232 Serial (UART) IC" ||
+		dev.ClassName != "(Defined at Interface level)" {
+		t.Fatalf("unexpected names: %+v", dev)
+	}
+	if name := dev.Interfaces[0].ClassName; name != "Vendor Specific Class" {
+		t.Fatalf("unexpected interface class name %q", name)
+	}
+	if name := dev.Name(); name != "Future Technology Devices International, Ltd FT232 Serial (UART) IC" {
+		t.Fatalf("unexpected name %q", name)
+	}
+	dev.Manufacturer, dev.Product = "FTDI", "FT232R USB UART"
+	if name := dev.Name(); name != "FTDI FT232R USB UART" {
+		t.Fatalf("unexpected name %q", name)
+	}
+}
+
+func TestTree(t *testing.T) {
+	root := t.TempDir()
+	const ctrl = "pci0000:00/0000:00:14.0"
+	if err := os.MkdirAll(filepath.Join(root, "sys", "devices", ctrl), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "sys", "devices", ctrl, "class"), []byte("0x0c0330\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeFakeUSBTree(t, root, []fakeUSBDevice{
+		{
+			path: ctrl + "/usb1",
+			files: map[string]string{
+				"busnum":                          "1",
+				"bDeviceClass":                    "09",
+				"speed":                           "480",
+				"1-0:1.0/usb1-port1/connect_type": "hotplug",
+				"1-0:1.0/usb1-port2/connect_type": "hotplug",
+			},
+		},
+		{
+			path: ctrl + "/usb1/1-0:1.0",
+			files: map[string]string{
+				"uevent": "DEVTYPE=usb_interface\nDRIVER=hub",
+			},
+		},
+		{
+			path: ctrl + "/usb1/1-1",
+			files: map[string]string{
+				"uevent":                         "DEVTYPE=usb_device\nDRIVER=usb\nPRODUCT=5e3/610/6060",
+				"busnum":                         "1",
+				"bDeviceClass":                   "09",
+				"1-1:1.0/1-1-port1/connect_type": "hotplug",
+				"1-1:1.0/1-1-port2/connect_type": "hotplug",
+			},
+		},
+		{
+			path: ctrl + "/usb1/1-1/1-1.2",
+			files: map[string]string{
+				"uevent":       "DEVTYPE=usb_device\nDRIVER=usb\nPRODUCT=403/6001/600",
+				"busnum":       "1",
+				"bDeviceClass": "00",
+			},
+		},
+		{
+			path: ctrl + "/usb2",
+			files: map[string]string{
+				"busnum":                          "2",
+				"bDeviceClass":                    "09",
+				"2-0:1.0/usb2-port1/connect_type": "hotplug",
+			},
+		},
+		{
+			path: ctrl + "/usb2/2-0:1.0",
+			files: map[string]string{
+				"uevent": "DEVTYPE=usb_interface\nDRIVER=hub",
+			},
+		},
+	})
+
+	info, err := New(option.WithChroot(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := info.Tree()
+	if len(tree.Controllers) != 1 {
+		t.Fatalf("expected 1 controller, got %d", len(tree.Controllers))
+	}
+	c := tree.Controllers[0]
+	if c.Name != "0000:00:14.0" || c.Type != ControllerTypeXHCI || c.PCIAddress == nil || c.PCIAddress.Device != "14" {
+		t.Fatalf("unexpected controller %+v", c)
+	}
+	if len(c.RootHubs) != 2 || c.RootHubs[0].Address.Busnum != 1 || c.RootHubs[1].Address.Busnum != 2 {
+		t.Fatalf("expected root hubs for buses 1 and 2, got %+v", c.RootHubs)
+	}
+	bus1 := c.RootHubs[0]
+	if len(bus1.Ports) != 2 || bus1.Ports[0].Hub == nil || !bus1.Ports[1].IsEmpty() {
+		t.Fatalf("expected a hub on port 1 and an empty port 2, got %+v", bus1.Ports)
+	}
+	hub := bus1.Ports[0].Hub
+	if hub.Address.String() != "1-1" || len(hub.Ports) != 2 {
+		t.Fatalf("unexpected hub %+v", hub)
+	}
+	if !hub.Ports[0].IsEmpty() || hub.Ports[1].Device == nil || hub.Ports[1].Address.String() != "1-1.2" {
+		t.Fatalf("expected a device on port 2 of the hub only, got %+v", hub.Ports)
+	}
+	if devs := c.Devices(); len(devs) != 2 {
+		t.Fatalf("expected 2 devices behind the controller, got %d", len(devs))
+	}
+	if len(c.Ports()) != 5 {
+		t.Fatalf("expected 5 ports behind the controller, got %d", len(c.Ports()))
+	}
+	if tree.ControllerOf(usbAddress.Address{Busnum: 2, Port: "1"}) != c {
+		t.Fatalf("expected bus 2 to belong to the controller")
+	}
+}
+
+func TestControllerType(t *testing.T) {
+	for _, tc := range []struct {
+		class, driver string
+		expected      ControllerType
+	}{
+		{"0x0c0330", "xhci_hcd", ControllerTypeXHCI},
+		{"0x0c0320", "ehci-pci", ControllerTypeEHCI},
+		{"0x0c0310", "ohci-pci", ControllerTypeOHCI},
+		{"0x0c0300", "uhci_hcd", ControllerTypeUHCI},
+		{"", "xhci-hcd", ControllerTypeXHCI},
+		{"", "dwc2", ControllerTypeUnknown},
+	} {
+		if ctype := controllerType(tc.class, tc.driver); ctype != tc.expected {
+			t.Errorf("class %q driver %q: expected %q, got %q", tc.class, tc.driver, tc.expected, ctype)
+		}
+	}
+}
+
+func TestPorts(t *testing.T) {
+	root := t.TempDir()
+	const ctrl = "pci0000:00/0000:00:14.0"
+	writeFakeUSBTree(t, root, []fakeUSBDevice{
+		{
+			path: ctrl + "/usb1",
+			files: map[string]string{
+				"1-0:1.0/usb1-port1/connect_type":                          "hotplug",
+				"1-0:1.0/usb1-port1/physical_location/panel":               "front",
+				"1-0:1.0/usb1-port1/physical_location/vertical_position":   "upper",
+				"1-0:1.0/usb1-port1/physical_location/horizontal_position": "left",
+				"1-0:1.0/usb1-port1/physical_location/dock":                "no",
+				"1-0:1.0/usb1-port1/physical_location/lid":                 "no",
+				"1-0:1.0/usb1-port2/connect_type":                          "hardwired",
+			},
+		},
+		{
+			path: ctrl + "/usb2",
+			files: map[string]string{
+				"2-0:1.0/usb2-port1/connect_type": "hotplug",
+			},
+		},
+	})
+	devDir := filepath.Join(root, "sys", "devices", ctrl)
+	for _, link := range []struct{ from, to string }{
+		{"usb1/1-0:1.0/usb1-port1", "usb2/2-0:1.0/usb2-port1"},
+		{"usb2/2-0:1.0/usb2-port1", "usb1/1-0:1.0/usb1-port1"},
+	} {
+		target := filepath.Join("..", "..", "..", link.to)
+		if err := os.Symlink(target, filepath.Join(devDir, link.from, "peer")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := New(option.WithChroot(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := info.Tree()
+	front := tree.PortByName("usb1-port1")
+	if front == nil || front.ConnectType != PortConnectTypeHotplug || !front.IsUserVisible() {
+		t.Fatalf("unexpected port %+v", front)
+	}
+	if front.PhysicalLocation == nil || front.PhysicalLocation.String() != "front upper left" {
+		t.Fatalf("unexpected physical location %+v", front.PhysicalLocation)
+	}
+	if front.USB3Peer != "usb2-port1" || tree.PortByName(front.USB3Peer).USB3Peer != "usb1-port1" {
+		t.Fatalf("expected usb1-port1 and usb2-port1 to be peers, got %q", front.USB3Peer)
+	}
+	internal := tree.PortByName("usb1-port2")
+	if internal.ConnectType != PortConnectTypeHardwired || internal.IsUserVisible() || internal.PhysicalLocation != nil {
+		t.Fatalf("unexpected port %+v", internal)
+	}
+}
+
+func TestClassDevices(t *testing.T) {
+	root := t.TempDir()
+	const modem = "pci0000:00/0000:00:14.0/usb1/1-2"
+	writeFakeUSBTree(t, root, []fakeUSBDevice{
+		{
+			path: modem,
+			files: map[string]string{
+				"uevent": "DEVTYPE=usb_device\nDRIVER=usb\nPRODUCT=2c7c/125/318",
+				"busnum": "1",
+			},
+		},
+		{path: modem + "/1-2:1.0"},
+		{path: modem + "/1-2:1.4"},
+	})
+	classDevs := []struct {
+		class, name, path string
+	}{
+		{"tty", "ttyUSB0", modem + "/1-2:1.0/ttyUSB0/tty/ttyUSB0"},
+		{"net", "wwan0", modem + "/1-2:1.4/net/wwan0"},
+		{"tty", "ttyS0", "platform/serial8250/tty/ttyS0"},
+	}
+	for _, cdev := range classDevs {
+		devDir := filepath.Join(root, "sys", "devices", cdev.path)
+		if err := os.MkdirAll(devDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		classDir := filepath.Join(root, "sys", "class", cdev.class)
+		if err := os.MkdirAll(classDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		target := filepath.Join("..", "..", "devices", cdev.path)
+		if err := os.Symlink(target, filepath.Join(classDir, cdev.name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := New(option.WithChroot(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev := info.Devices[0]
+	expected := []*ClassDevice{
+		{Class: "tty", Name: "ttyUSB0"},
+		{Class: "net", Name: "wwan0"},
+	}
+	if !reflect.DeepEqual(dev.AllClassDevices(), expected) {
+		t.Fatalf("expected %v, got %v", expected, dev.AllClassDevices())
+	}
+	owner, iface := info.FindByClassDevice("net", "wwan0")
+	if owner != dev || iface == nil || iface.Name != "1-2:1.4" {
+		t.Fatalf("expected wwan0 to belong to interface 1-2:1.4, got %v %v", owner, iface)
+	}
+	if owner, _ := info.FindByClassDevice("tty", "ttyS0"); owner != nil {
+		t.Fatalf("expected ttyS0 not to be a USB device, got %v", owner)
+	}
+}
+
+func TestSpeedDegradedBOS(t *testing.T) {
+	// BOS header, then USB 2.0 extension and SuperSpeed USB capabilities
+	superSpeedBOS := "\x05\x0f\x16\x00\x02" +
+		"\x07\x10\x02\x06\x00\x00\x00" +
+		"\x0a\x10\x03\x00\x0e\x00\x01\x0a\xff\x07"
+	usb2BOS := "\x05\x0f\x0c\x00\x01" +
+		"\x07\x10\x02\x06\x00\x00\x00"
+	root := t.TempDir()
+	writeFakeUSBTree(t, root, []fakeUSBDevice{
+		{
+			// a USB 3 stick fallen back to USB 2
+			path: "pci0000:00/0000:00:14.0/usb1/1-1",
+			files: map[string]string{
+				"uevent":          "DEVTYPE=usb_device\nDRIVER=usb\nPRODUCT=781/5583/100\nTYPE=0/0/0",
+				"busnum":          "1",
+				"speed":           "480",
+				"version":         " 2.10",
+				"bos_descriptors": superSpeedBOS,
+			},
+		},
+		{
+			// a USB 2 device with link power management
+			path: "pci0000:00/0000:00:14.0/usb1/1-2",
+			files: map[string]string{
+				"uevent":          "DEVTYPE=usb_device\nDRIVER=usb\nPRODUCT=46d/c52b/1211\nTYPE=0/0/0",
+				"busnum":          "1",
+				"speed":           "480",
+				"version":         " 2.10",
+				"bos_descriptors": usb2BOS,
+			},
+		},
+	})
+
+	info, err := New(option.WithChroot(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Devices) != 2 {
+		t.Fatalf("expected 2 devices, got %d", len(info.Devices))
+	}
+	stick, lpm := info.Devices[0], info.Devices[1]
+	if !stick.SuperSpeedCapable || !stick.IsSpeedDegraded() {
+		t.Fatalf("expected a SuperSpeed device at 480 Mbps to be degraded, got %+v", stick)
+	}
+	if lpm.SuperSpeedCapable || lpm.IsSpeedDegraded() {
+		t.Fatalf("expected a USB 2 device not to be degraded, got %+v", lpm)
+	}
+}
@@ -0,0 +1,90 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package usbdb
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// FromReader parses a usb.ids database.
+//
+// The file lists vendors, each followed by its products indented by a tab,
+// then device classes, introduced by a "C " prefix, each followed by its
+// subclasses and protocols indented by one and two tabs. The sections which
+// follow, e.g. HID descriptor types or language IDs, are skipped.
+func FromReader(r io.Reader) (*DB, error) {
+	db := &DB{
+		Classes:  map[string]*Class{},
+		Vendors:  map[string]*Vendor{},
+		Products: map[string]*Product{},
+	}
+	var (
+		vendor   *Vendor
+		class    *Class
+		subclass *Subclass
+	)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "\t\t"):
+			// interfaces of products are not tracked
+			if subclass == nil {
+				continue
+			}
+			if id, name, ok := parseEntry(line[2:], 2); ok {
+				subclass.Protocols = append(subclass.Protocols, &Protocol{ID: id, Name: name})
+			}
+		case strings.HasPrefix(line, "\t"):
+			if vendor != nil {
+				if id, name, ok := parseEntry(line[1:], 4); ok {
+					product := &Product{VendorID: vendor.ID, ID: id, Name: name}
+					vendor.Products = append(vendor.Products, product)
+					db.Products[vendor.ID+id] = product
+				}
+			} else if class != nil {
+				if id, name, ok := parseEntry(line[1:], 2); ok {
+					subclass = &Subclass{ID: id, Name: name}
+					class.Subclasses = append(class.Subclasses, subclass)
+				}
+			}
+		case strings.HasPrefix(line, "C "):
+			vendor, class, subclass = nil, nil, nil
+			if id, name, ok := parseEntry(line[2:], 2); ok {
+				class = &Class{ID: id, Name: name}
+				db.Classes[id] = class
+			}
+		default:
+			vendor, class, subclass = nil, nil, nil
+			if id, name, ok := parseEntry(line, 4); ok {
+				vendor = &Vendor{ID: id, Name: name}
+				db.Vendors[id] = vendor
+			}
+		}
+	}
+	return db, scanner.Err()
+}
+
+// parseEntry parses an "<id>  <name>" line, where the ID is made of idLen
+// hexadecimal digits
+func parseEntry(line string, idLen int) (string, string, bool) {
+	if len(line) < idLen+1 || line[idLen] != ' ' {
+		return "", "", false
+	}
+	id := strings.ToLower(line[:idLen])
+	for _, c := range id {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return "", "", false
+		}
+	}
+	return id, strings.TrimSpace(line[idLen:]), true
+}
@@ -0,0 +1,210 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+// Package usbdb loads the USB ID database, usb.ids, which maps USB vendor,
+// product and class identifiers to names. It discovers the database file the
+// same way github.com/jaypipes/pcidb discovers pci.ids, with USBDB_*
+// environment variables in place of the PCIDB_* ones.
+package usbdb
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	EnvVarChroot    = "USBDB_CHROOT"
+	EnvVarPath      = "USBDB_PATH"
+	EnvVarCacheOnly = "USBDB_CACHE_ONLY"
+	EnvVarCachePath = "USBDB_CACHE_PATH"
+)
+
+// ErrNoDB is returned when no usb.ids file could be found
+var ErrNoDB = errors.New("no usb.ids database file found")
+
+// DB holds the content of a usb.ids database.
+type DB struct {
+	// Classes is a map, keyed by class ID, e.g. "03", of USB class
+	// information
+	Classes map[string]*Class `json:"classes"`
+	// Vendors is a map, keyed by vendor ID, e.g. "0403", of USB vendor
+	// information
+	Vendors map[string]*Vendor `json:"vendors"`
+	// Products is a map, keyed by vendor ID + product ID, e.g. "04036001",
+	// of USB product information
+	Products map[string]*Product `json:"products"`
+}
+
+// Vendor provides information about a USB device vendor
+type Vendor struct {
+	ID       string     `json:"id"`
+	Name     string     `json:"name"`
+	Products []*Product `json:"products"`
+}
+
+// Product provides information about a USB device model
+type Product struct {
+	VendorID string `json:"vendor_id"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+}
+
+// Class provides information about a USB device or interface class
+type Class struct {
+	ID         string      `json:"id"`
+	Name       string      `json:"name"`
+	Subclasses []*Subclass `json:"subclasses"`
+}
+
+// Subclass provides information about a USB subclass
+type Subclass struct {
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	Protocols []*Protocol `json:"protocols"`
+}
+
+// Protocol provides information about a USB class protocol
+type Protocol struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// WithOption is used to represent optionally-configured settings
+type WithOption struct {
+	// Chroot is the directory used when discovering usb.ids files
+	Chroot *string
+	// CacheOnly restricts the discovery to the cache path
+	CacheOnly *bool
+	// CachePath overrides the cache path, which defaults to
+	// $HOME/.cache/usb.ids
+	CachePath *string
+	// Path points to a usb.ids or usb.ids.gz file in a non-standard location,
+	// bypassing the discovery
+	Path *string
+}
+
+// WithChroot overrides the root directory used for discovery of usb.ids
+// database files.
+func WithChroot(dir string) *WithOption {
+	return &WithOption{Chroot: &dir}
+}
+
+// WithCachePath overrides the path usbdb first looks up a usb.ids database
+// file at.
+func WithCachePath(path string) *WithOption {
+	return &WithOption{CachePath: &path}
+}
+
+// WithCacheOnly forces usbdb to only use the usb.ids database file at the
+// cache path.
+func WithCacheOnly() *WithOption {
+	cacheOnly := true
+	return &WithOption{CacheOnly: &cacheOnly}
+}
+
+// WithPath overrides the usb.ids database file discovery and points usbdb at
+// a known location of a usb.ids or usb.ids.gz database file.
+func WithPath(path string) *WithOption {
+	return &WithOption{Path: &path}
+}
+
+// New returns a pointer to a DB struct which contains information you can use
+// to query USB vendor, product and class information.
+//
+// It accepts zero or more pointers to WithOption structs, which take
+// precedence over the USBDB_* environment variables.
+func New(opts ...*WithOption) (*DB, error) {
+	merged := mergeOptions(opts...)
+	for _, fp := range searchPaths(merged) {
+		if _, err := os.Stat(fp); err == nil {
+			return fromPath(fp)
+		}
+	}
+	return nil, ErrNoDB
+}
+
+func fromPath(fp string) (*DB, error) {
+	f, err := os.Open(fp)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if filepath.Ext(fp) == ".gz" {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", fp, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return FromReader(r)
+}
+
+func mergeOptions(opts ...*WithOption) *WithOption {
+	merged := &WithOption{}
+	if val, exists := os.LookupEnv(EnvVarChroot); exists {
+		merged.Chroot = &val
+	}
+	if val, exists := os.LookupEnv(EnvVarPath); exists {
+		merged.Path = &val
+	}
+	if val, exists := os.LookupEnv(EnvVarCachePath); exists {
+		merged.CachePath = &val
+	}
+	if val, exists := os.LookupEnv(EnvVarCacheOnly); exists {
+		if parsed, err := strconv.ParseBool(val); err == nil {
+			merged.CacheOnly = &parsed
+		}
+	}
+	for _, opt := range opts {
+		if opt.Chroot != nil {
+			merged.Chroot = opt.Chroot
+		}
+		if opt.CacheOnly != nil {
+			merged.CacheOnly = opt.CacheOnly
+		}
+		if opt.CachePath != nil {
+			merged.CachePath = opt.CachePath
+		}
+		if opt.Path != nil {
+			merged.Path = opt.Path
+		}
+	}
+	return merged
+}
+
+// searchPaths returns the paths to look for a usb.ids database file at, in
+// order
+func searchPaths(opts *WithOption) []string {
+	if opts.Path != nil && *opts.Path != "" {
+		return []string{*opts.Path}
+	}
+	paths := []string{}
+	if opts.CachePath != nil {
+		paths = append(paths, *opts.CachePath)
+	} else if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".cache", "usb.ids"))
+	}
+	if opts.CacheOnly != nil && *opts.CacheOnly {
+		return paths
+	}
+	root := "/"
+	if opts.Chroot != nil && *opts.Chroot != "" {
+		root = *opts.Chroot
+	}
+	for _, name := range []string{"usb.ids", "usb.ids.gz"} {
+		for _, dir := range []string{"hwdata", "misc"} {
+			paths = append(paths, filepath.Join(root, "usr", "share", dir, name))
+		}
+	}
+	return paths
+}
@@ -0,0 +1,110 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package usbdb_test
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zededa/ghw/pkg/usb/usbdb"
+)
+
+const testUSBIDs = `#
+#	List of USB ID's
+#
+# Version: 2024.03.18
+# Date:    2024-03-18 20:34:02
+#
+
+0403  Future Technology Devices International, Ltd
+	6001  FT232 Serial (UART) IC
+	6010  FT2232C/D/H Dual UART/FIFO IC
+046a  Cherry GmbH
+	a087  Keyboard
+		00  Keyboard interface
+
+# List of known device classes, subclasses and protocols
+
+C 00  (Defined at Interface level)
+C 03  Human Interface Device
+	01  Boot Interface Subclass
+		01  Keyboard
+		02  Mouse
+C 09  Hub
+	00  Unused
+		00  Full speed (or root) hub
+
+# List of Audio Class Terminal Types
+
+AT 0100  USB Undefined
+	0101  USB Streaming
+HID 00  None
+L 0409  English
+	01  United States
+`
+
+func TestFromReader(t *testing.T) {
+	db, err := usbdb.FromReader(strings.NewReader(testUSBIDs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(db.Vendors) != 2 || len(db.Products) != 3 || len(db.Classes) != 3 {
+		t.Fatalf("expected 2 vendors, 3 products and 3 classes, got %d, %d and %d",
+			len(db.Vendors), len(db.Products), len(db.Classes))
+	}
+	if name := db.Vendors["0403"].Name; name != "Future Technology Devices International, Ltd" {
+		t.Fatalf("unexpected vendor name %q", name)
+	}
+	if name := db.Products["04036001"].Name; name != "FT232 Serial (UART) IC" {
+		t.Fatalf("unexpected product name %q", name)
+	}
+	hid := db.Classes["03"]
+	if hid.Name != "Human Interface Device" || len(hid.Subclasses) != 1 {
+		t.Fatalf("unexpected class %+v", hid)
+	}
+	if protocols := hid.Subclasses[0].Protocols; len(protocols) != 2 || protocols[1].Name != "Mouse" {
+		t.Fatalf("unexpected protocols %+v", protocols)
+	}
+}
+
+func TestNew(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "usr", "share", "hwdata")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(dir, "usb.ids.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	if _, err := gz.Write([]byte(testUSBIDs)); err != nil {
+		t.Fatal(err)
+	}
+	gz.Close()
+	f.Close()
+
+	t.Setenv(usbdb.EnvVarPath, "")
+	db, err := usbdb.New(
+		usbdb.WithChroot(root),
+		usbdb.WithCachePath(filepath.Join(root, "nonexistent")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := db.Vendors["046a"]; !ok {
+		t.Fatalf("expected vendor 046a in the database")
+	}
+
+	_, err = usbdb.New(usbdb.WithPath(filepath.Join(root, "nonexistent")))
+	if err != usbdb.ErrNoDB {
+		t.Fatalf("expected ErrNoDB, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+//
+// Use and distribution licensed under the Apache license version 2.
+//
+// See the COPYING file in the root project directory for full text.
+//
+
+package usb
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/zededa/ghw/pkg/option"
+	"github.com/zededa/ghw/pkg/usb/usbdb"
+)
+
+// WithUSBDB allows you to provide a custom instance of the USB ID database
+// (usbdb.DB) to ghw, such as one created with custom usbdb.WithOption
+// settings, instead of letting ghw load the USB ID database automatically.
+func WithUSBDB(db *usbdb.DB) option.Option {
+	return func(opts *option.Options) {
+		opts.USBDB = db
+	}
+}
+
+// loadUSBDB returns the USB ID database used to resolve names: the one
+// supplied by the caller if any, the host one otherwise. Returns nil if the
+// host has none, in which case names are left empty.
+func loadUSBDB(opts *option.Options) *usbdb.DB {
+	if db, ok := opts.USBDB.(*usbdb.DB); ok && db != nil {
+		return db
+	}
+	db, err := usbdb.New()
+	if err != nil {
+		return nil
+	}
+	return db
+}
+
+// setNames fills the vendor, product and class names of the devices and
+// their interfaces from the given database
+func (i *Info) setNames(db *usbdb.DB) {
+	if db == nil {
+		return
+	}
+	className := func(id string) string {
+		if class, ok := db.Classes[normalizeID(id, 2)]; ok {
+			return class.Name
+		}
+		return ""
+	}
+	for _, dev := range i.Devices {
+		vendorID := normalizeID(dev.VendorID, 4)
+		if vendor, ok := db.Vendors[vendorID]; ok {
+			dev.VendorName = vendor.Name
+		}
+		if product, ok := db.Products[vendorID+normalizeID(dev.ProductID, 4)]; ok {
+			dev.ProductName = product.Name
+		}
+		dev.ClassName = className(dev.Class)
+		for _, iface := range dev.Interfaces {
+			iface.ClassName = className(iface.Class)
+		}
+	}
+}
+
+// normalizeID formats a hexadecimal ID the way usb.ids does, e.g. "046a" for
+// "46a" as found in uevent files. Returns an empty string if the ID is not
+// hexadecimal.
+func normalizeID(id string, digits int) string {
+	num, err := strconv.ParseUint(id, 16, 16)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%0*x", digits, num)
+}
+
+// Name returns a human readable name for the device, e.g. "FTDI FT232R USB
+// UART", preferring the strings reported by the device over the names from
+// the USB ID database, and falling back to the vendor and product IDs.
+func (d *Device) Name() string {
+	vendor := d.Manufacturer
+	if vendor == "" {
+		vendor = d.VendorName
+	}
+	product := d.Product
+	if product == "" {
+		product = d.ProductName
+	}
+	switch {
+	case vendor != "" && product != "":
+		return vendor + " " + product
+	case product != "":
+		return product
+	}
+	return normalizeID(d.VendorID, 4) + ":" + normalizeID(d.ProductID, 4)
+}